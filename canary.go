@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// instanceHeader is the request header of the instance ID of the app,
+// which is matched by the canary rules.
+const instanceHeader = "X-Appconfig-Instance"
+
+// canaryRule is the body to create or widen the canary.
+type canaryRule struct {
+	Value     *string  `json:"value"`
+	IPs       []string `json:"ips"`
+	Instances []string `json:"instances"`
+	Percent   int      `json:"percent"`
+}
+
+// checkCanary checks whether the rules of the canary are valid.
+func checkCanary(c store.Canary) error {
+	for _, ip := range c.IPs {
+		if strings.IndexByte(ip, '/') > -1 {
+			if _, _, err := net.ParseCIDR(ip); err != nil {
+				return err
+			}
+		} else if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid IP '%s'", ip)
+		}
+	}
+	if c.Percent < 0 || c.Percent > 100 {
+		return fmt.Errorf("the percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// canaryBucket returns the bucket between 0 and 99 of the instance,
+// which is deterministic for the key and the instance.
+func canaryBucket(c store.Canary, instance string) int {
+	s := strings.Join([]string{c.Dc, c.Env, c.App, c.Key, instance}, "/")
+	return int(crc32.ChecksumIEEE([]byte(s)) % 100)
+}
+
+// matchCanary reports whether the client with the ip and the instance ID
+// matches the rules of the canary.
+func matchCanary(c store.Canary, ip, instance string) bool {
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, rule := range c.IPs {
+			if strings.IndexByte(rule, '/') < 0 {
+				if addr.Equal(net.ParseIP(rule)) {
+					return true
+				}
+			} else if _, n, err := net.ParseCIDR(rule); err == nil && n.Contains(addr) {
+				return true
+			}
+		}
+	}
+
+	if instance == "" {
+		return false
+	}
+	for _, i := range c.Instances {
+		if i == instance {
+			return true
+		}
+	}
+	return c.Percent > 0 && canaryBucket(c, instance) < c.Percent
+}
+
+// configClient is the client of the app getting the config, which is matched
+// by the canaries.
+type configClient struct {
+	IP       string
+	Instance string
+}
+
+// getRequestClient returns the client of the app sending the request.
+func getRequestClient(r *http.Request) configClient {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return configClient{IP: ip, Instance: r.Header.Get(instanceHeader)}
+}
+
+// matchSubscriber reports whether the callback matches the canary, the id
+// of which is regarded as the instance ID and the host of the callback URL
+// is regarded as the IP.
+func matchSubscriber(c store.Canary, id, cb string) bool {
+	var ip string
+	if u, err := url.Parse(cb); err == nil {
+		ip = u.Hostname()
+	}
+	return matchCanary(c, ip, id)
+}
+
+// getMatchedCanary returns the canary of the key if the client matches it,
+// or nil.
+func getMatchedCanary(client configClient, dc, env, app, key string) (
+	*store.Canary, error) {
+	c, err := backend.GetCanary(dc, env, app, key)
+	if err == store.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !matchCanary(c, client.IP, client.Instance) {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// overlayCanaries replaces the values of the keys of the app in kvs with
+// the candidate values of the canaries matched by the client, and returns
+// the newest version of them.
+func overlayCanaries(client configClient, dc, env, app string,
+	kvs map[string]string, version int64) (int64, error) {
+	canaries, err := backend.GetCanaries(dc, env, app)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range canaries {
+		if _, ok := kvs[c.Key]; ok && matchCanary(c, client.IP, client.Instance) {
+			kvs[c.Key] = c.Value
+			if c.Time > version {
+				version = c.Time
+			}
+		}
+	}
+	return version, nil
+}
+
+// getCanaryRule parses the canary rule from the body of the request.
+func getCanaryRule(r *http.Request) (rule canaryRule, err error) {
+	body, err := http2.GetBody(r)
+	if err == nil {
+		err = json.Unmarshal(body, &rule)
+	}
+	return
+}
+
+// CreateCanary creates the canary of the key, then notifies the callbacks
+// matching it with the candidate value.
+func CreateCanary(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	rule, err := getCanaryRule(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	} else if rule.Value == nil {
+		return http2.String(w, http.StatusBadRequest, "missing the value")
+	}
+
+	c := store.Canary{Dc: dc, Env: env, App: app, Key: key, Value: *rule.Value,
+		IPs: rule.IPs, Instances: rule.Instances, Percent: rule.Percent}
+	if err = checkCanary(c); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	c, err = backend.AddCanary(c)
+	printLog(err, "Create the canary: dc=%s, env=%s, app=%s, key=%s", dc, env,
+		app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	err = notifyCallbacksIf(dc, env, app, key, c.Value, func(id, cb string) bool {
+		return matchSubscriber(c, id, cb)
+	})
+	if err != nil {
+		return renderError(w, err)
+	}
+	return renderCanary(w, c)
+}
+
+// WidenCanary adds the IPs and the instances into the rules of the canary,
+// and raises the percent, then notifies the callbacks newly matching it
+// with the candidate value.
+func WidenCanary(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	rule, err := getCanaryRule(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	} else if rule.Value != nil {
+		return http2.String(w, http.StatusBadRequest,
+			"the value cannot be changed")
+	}
+
+	old, err := backend.GetCanary(dc, env, app, key)
+	if err != nil {
+		return renderError(w, err)
+	} else if rule.Percent < old.Percent {
+		return http2.String(w, http.StatusBadRequest,
+			"the percent cannot be less than %d", old.Percent)
+	}
+
+	c := old
+	c.IPs = mergeStrings(old.IPs, rule.IPs)
+	c.Instances = mergeStrings(old.Instances, rule.Instances)
+	c.Percent = rule.Percent
+	if err = checkCanary(c); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	c, err = backend.UpdateCanary(c)
+	printLog(err, "Widen the canary: dc=%s, env=%s, app=%s, key=%s", dc, env,
+		app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	err = notifyCallbacksIf(dc, env, app, key, c.Value, func(id, cb string) bool {
+		return matchSubscriber(c, id, cb) && !matchSubscriber(old, id, cb)
+	})
+	if err != nil {
+		return renderError(w, err)
+	}
+	return renderCanary(w, c)
+}
+
+// PromoteCanary sets the candidate value as the stable value and deletes
+// the canary, then notifies the callbacks not matching it.
+func PromoteCanary(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	c, err := backend.GetCanary(dc, env, app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	err = backend.SetKeyValue(dc, env, app, key, c.Value)
+	if err == nil {
+		err = backend.DeleteCanary(dc, env, app, key)
+	}
+	printLog(err, "Promote the canary: dc=%s, env=%s, app=%s, key=%s", dc, env,
+		app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	// The callbacks matching the canary have got the candidate value.
+	err = notifyCallbacksIf(dc, env, app, key, c.Value, func(id, cb string) bool {
+		return !matchSubscriber(c, id, cb)
+	})
+	return renderError(w, err)
+}
+
+// AbortCanary deletes the canary, then notifies the callbacks matching it
+// with the stable value.
+func AbortCanary(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+
+	c, err := backend.GetCanary(dc, env, app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	err = backend.DeleteCanary(dc, env, app, key)
+	printLog(err, "Abort the canary: dc=%s, env=%s, app=%s, key=%s", dc, env,
+		app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	v, _, err := backend.AppGetConfig(dc, env, app, key, 0)
+	if err == store.ErrNotFound {
+		return nil
+	} else if err == nil {
+		err = notifyCallbacksIf(dc, env, app, key, v, func(id, cb string) bool {
+			return matchSubscriber(c, id, cb)
+		})
+	}
+	return renderError(w, err)
+}
+
+// renderCanary renders the canary, the candidate value of which is masked
+// if the key is sensitive.
+func renderCanary(w http.ResponseWriter, c store.Canary) (err error) {
+	if c.Value, err = maskValue(c.Dc, c.Env, c.App, c.Key, c.Value); err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"canary": c})
+}
+
+// GetCanaries returns all the canaries of the app.
+func GetCanaries(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	canaries, err := backend.GetCanaries(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if canaries == nil {
+		canaries = []store.Canary{}
+	}
+	for i, c := range canaries {
+		if canaries[i].Value, err = maskValue(c.Dc, c.Env, c.App, c.Key,
+			c.Value); err != nil {
+			return renderError(w, err)
+		}
+	}
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"canaries": canaries})
+}
+
+// GetCanary returns the canary of the key.
+func GetCanary(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	c, err := backend.GetCanary(vs["dc"], vs["env"], vs["app"], vs["key"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	return renderCanary(w, c)
+}
+
+// mergeStrings returns the union of a and b, which keeps the order.
+func mergeStrings(a, b []string) []string {
+	ss := append([]string(nil), a...)
+	for _, s := range b {
+		var exist bool
+		for _, _s := range ss {
+			if s == _s {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// identityHeader is the request header of the identity of the user,
+// who proposes, approves or rejects the changes.
+//
+// The header is set by the client, so it should be set only by the proxies
+// authenticating the users, which are given by setTrustedProxies.
+const identityHeader = "X-Appconfig-User"
+
+var (
+	// protectedEnvs is the set of the protected envs, the element of which
+	// is "env" for the env in any dc, or "dc/env".
+	protectedEnvs = map[string]bool{}
+
+	// requiredApprovals is the number of the approvals required by a change.
+	requiredApprovals = 1
+
+	// trustedProxies is the networks of the proxies, from which the header
+	// identityHeader is trusted. If empty, it is trusted from any client.
+	trustedProxies []*net.IPNet
+)
+
+// setTrustedProxies sets the trusted proxies, which are separated by comma.
+// Each of them is an IP or a CIDR.
+func setTrustedProxies(proxies string) error {
+	nets := make([]*net.IPNet, 0, 4)
+	for _, proxy := range strings.Split(proxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip == nil {
+				return fmt.Errorf("invalid trusted proxy '%s'", proxy)
+			} else if ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy '%s'", proxy)
+		}
+		nets = append(nets, ipnet)
+	}
+	trustedProxies = nets
+	return nil
+}
+
+// getIdentity returns the identity of the user from the client with the ip,
+// or "" if the client is not a trusted proxy.
+func getIdentity(ip, identity string) string {
+	if len(trustedProxies) == 0 {
+		return identity
+	}
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, ipnet := range trustedProxies {
+			if ipnet.Contains(addr) {
+				return identity
+			}
+		}
+	}
+	return ""
+}
+
+// getRequestUser returns the identity of the user of the request, which is
+// the header identityHeader set by a trusted proxy.
+func getRequestUser(r *http.Request) string {
+	return getIdentity(getRequestClient(r).IP, r.Header.Get(identityHeader))
+}
+
+// setProtectedEnvs sets the protected envs, which are separated by comma,
+// and the number of the approvals required by a change.
+func setProtectedEnvs(envs string, approvals int) {
+	protectedEnvs = make(map[string]bool, 4)
+	for _, env := range strings.Split(envs, ",") {
+		if env = strings.TrimSpace(env); env != "" {
+			protectedEnvs[env] = true
+		}
+	}
+	if approvals > 0 {
+		requiredApprovals = approvals
+	}
+}
+
+// isProtected reports whether the env in dc is protected, the change to
+// which needs the approval.
+func isProtected(dc, env string) bool {
+	return protectedEnvs[env] || protectedEnvs[dc+"/"+env]
+}
+
+// isProtectedDc reports whether an env in dc may be protected, which is
+// used when the envs in dc are unknown, such as the whole dc in the trash.
+func isProtectedDc(dc string) bool {
+	for env := range protectedEnvs {
+		if i := strings.IndexByte(env, '/'); i < 0 || env[:i] == dc {
+			return true
+		}
+	}
+	return false
+}
+
+// renderProtected refuses to set the values directly in the protected env.
+func renderProtected(w http.ResponseWriter, dc, env string) error {
+	return http2.String(w, http.StatusForbidden,
+		"the env '%s' in the dc '%s' is protected, so upload the keys for approval",
+		env, dc)
+}
+
+// protectedError returns the error of changing the protected env directly,
+// which is rendered as 403 like renderProtected.
+func protectedError(dc, env string) error {
+	return http2.NewHTTPError(http.StatusForbidden, fmt.Errorf(
+		"the env '%s' in the dc '%s' is protected, so upload the keys for approval",
+		env, dc))
+}
+
+// checkUnprotected returns the error like protectedError if the env in dc
+// is protected, or any env in dc if env is "".
+func checkUnprotected(dc, env string) error {
+	envs := []string{env}
+	if env == "" {
+		dcs, err := backend.GetAllDcAndEnvs()
+		if err != nil {
+			return err
+		}
+		envs = dcs[dc]
+	}
+
+	for _, env := range envs {
+		if isProtected(dc, env) {
+			return protectedError(dc, env)
+		}
+	}
+	return nil
+}
+
+// getChangeDiff returns the unified diff from the value at the base version
+// of the change to the proposed value, and whether the key of the pending
+// change has been changed since proposed.
+func getChangeDiff(c store.Change) (diff string, stale bool, err error) {
+	var old string
+	if c.Base > 0 {
+		old, _, err = backend.AppGetConfig(c.Dc, c.Env, c.App, c.Key, c.Base)
+		if err != nil && err != store.ErrNotFound {
+			return
+		}
+	}
+
+	diff = unifiedDiff(fmt.Sprintf("%s@%d", c.Key, c.Base),
+		fmt.Sprintf("%s@%s", c.Key, c.ID), old, c.Value)
+	if c.Status != store.ChangePending {
+		return diff, false, nil
+	}
+
+	_, latest, err := backend.AppGetConfig(c.Dc, c.Env, c.App, c.Key, 0)
+	if err == store.ErrNotFound {
+		err = nil
+	} else if err != nil {
+		return
+	}
+	return diff, latest != c.Base, nil
+}
+
+// maskChange masks the proposed value of the change and its diff if the key
+// is sensitive.
+func maskChange(c store.Change, diff string) (store.Change, string, error) {
+	if ok, err := isSensitive(c.Dc, c.Env, c.App, c.Key); err != nil || !ok {
+		return c, diff, err
+	}
+	c.Value = sensitiveMask
+	return c, maskDiff(diff), nil
+}
+
+// renderChange renders the change with its diff.
+func renderChange(w http.ResponseWriter, code int, c store.Change) error {
+	diff, stale, err := getChangeDiff(c)
+	if err == nil {
+		c, diff, err = maskChange(c, diff)
+	}
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, code, map[string]interface{}{
+		"change": c,
+		"diff":   diff,
+		"stale":  stale,
+	})
+}
+
+// proposeChange proposes the change of the value of the key in the protected
+// env by the requester, which is set only when approved.
+func proposeChange(dc, env, app, key, value, requester string) (store.Change,
+	error) {
+	if requester == "" {
+		return store.Change{}, http2.NewHTTPError(http.StatusUnauthorized,
+			fmt.Errorf("missing the requester %s", identityHeader))
+	}
+
+	_, base, err := backend.AppGetConfig(dc, env, app, key, 0)
+	if err != nil && err != store.ErrNotFound {
+		return store.Change{}, err
+	}
+
+	c, err := backend.AddChange(store.Change{Dc: dc, Env: env, App: app,
+		Key: key, Value: value, Base: base, Requester: requester})
+	printLog(err, "Propose the change, dc=%s, env=%s, app=%s, key=%s, requester=%s",
+		dc, env, app, key, requester)
+	return c, err
+}
+
+// GetChanges returns the changes, which are filtered by the query arguments
+// dc, env and status.
+func GetChanges(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	changes, err := backend.GetChanges(http2.GetQuery(query, "dc"),
+		http2.GetQuery(query, "env"), http2.GetQuery(query, "status"))
+	if err != nil {
+		return renderError(w, err)
+	}
+	if changes == nil {
+		changes = []store.Change{}
+	}
+	for i, c := range changes {
+		if changes[i].Value, err = maskValue(c.Dc, c.Env, c.App, c.Key,
+			c.Value); err != nil {
+			return renderError(w, err)
+		}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"changes": changes})
+}
+
+// GetChange returns the change with its diff.
+func GetChange(w http.ResponseWriter, r *http.Request) error {
+	c, err := backend.GetChange(mux.Vars(r)["id"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	return renderChange(w, http.StatusOK, c)
+}
+
+// getReviewedChange returns the pending change to be reviewed by the reviewer,
+// who must be different from the requester.
+//
+// If failed, it renders the error and returns nil.
+func getReviewedChange(w http.ResponseWriter, r *http.Request) (
+	*store.Change, string, error) {
+	reviewer := getRequestUser(r)
+	if reviewer == "" {
+		return nil, "", http2.String(w, http.StatusUnauthorized,
+			"missing the header "+identityHeader)
+	}
+
+	c, err := backend.GetChange(mux.Vars(r)["id"])
+	if err != nil {
+		return nil, "", renderError(w, err)
+	} else if c.Status != store.ChangePending {
+		return nil, "", http2.String(w, http.StatusConflict,
+			"the change has been %s", c.Status)
+	} else if c.Requester == reviewer {
+		return nil, "", http2.String(w, http.StatusForbidden,
+			"the requester cannot review the change")
+	}
+	return &c, reviewer, nil
+}
+
+// ApproveChange approves the change. When the change gets enough approvals,
+// its value is set and the callbacks are notified.
+func ApproveChange(w http.ResponseWriter, r *http.Request) error {
+	c, approver, err := getReviewedChange(w, r)
+	if c == nil {
+		return err
+	}
+
+	for _, a := range c.Approvers {
+		if a == approver {
+			return http2.String(w, http.StatusConflict,
+				"the change has been approved by %s", approver)
+		}
+	}
+	c.Approvers = append(c.Approvers, approver)
+
+	if len(c.Approvers) >= requiredApprovals {
+		// The approvers review the diff against the base version,
+		// so refuse to overwrite the changes made since then.
+		_, latest, err := backend.AppGetConfig(c.Dc, c.Env, c.App, c.Key, 0)
+		if err != nil && err != store.ErrNotFound {
+			return renderError(w, err)
+		} else if latest != c.Base {
+			return http2.String(w, http.StatusConflict,
+				"the key has been changed since proposed")
+		}
+		c.Status = store.ChangeApproved
+	}
+
+	// Only one of the concurrent approvals succeeds, so the value is set once.
+	change, err := backend.UpdateChange(*c)
+	printLog(err, "Approve the change id=%s, approver=%s", c.ID, approver)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	if change.Status == store.ChangeApproved {
+		err = setKeyValue(change.Dc, change.Env, change.App, change.Key,
+			change.Value)
+		printLog(err, "Set the approved change id=%s, dc=%s, env=%s, app=%s, key=%s",
+			change.ID, change.Dc, change.Env, change.App, change.Key)
+		if err != nil {
+			// Withdraw the approval, so that the change can be approved again.
+			change.Status = store.ChangePending
+			change.Approvers = change.Approvers[:len(change.Approvers)-1]
+			_, _err := backend.UpdateChange(change)
+			printLog(_err, "Withdraw the approval of the change id=%s, approver=%s",
+				change.ID, approver)
+			return renderError(w, err)
+		}
+
+		// Record the version set by the change.
+		_, change.Version, err = backend.AppGetConfig(change.Dc, change.Env,
+			change.App, change.Key, 0)
+		if err == nil {
+			change, err = backend.UpdateChange(change)
+		}
+		if err != nil {
+			return renderError(w, err)
+		}
+	}
+
+	return renderChange(w, http.StatusOK, change)
+}
+
+// RejectChange rejects the change, the reason of which is the body.
+func RejectChange(w http.ResponseWriter, r *http.Request) error {
+	c, reviewer, err := getReviewedChange(w, r)
+	if c == nil {
+		return err
+	}
+
+	reason, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	c.Status = store.ChangeRejected
+	c.Reviewer = reviewer
+	c.Reason = string(reason)
+	change, err := backend.UpdateChange(*c)
+	printLog(err, "Reject the change id=%s, reviewer=%s", c.ID, reviewer)
+	if err != nil {
+		return renderError(w, err)
+	}
+	return renderChange(w, http.StatusOK, change)
+}
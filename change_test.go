@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestProtectedEnv(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "prod")
+	backend.SetKeyValue("bj", "prod", "a", "k1", "v1")
+	backend.SetKeyValue("bj", "prod", "a", "k2", "v2")
+	trash, err := backend.TrashConfig("bj", "prod", "a", "k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setProtectedEnvs("prod", 1)
+	defer setProtectedEnvs("", 0)
+
+	cases := []struct{ method, path, body string }{
+		{"POST", "/v1/admin/bj/prod/a/k1/rollback?steps=1", ""},
+		{"POST", "/v1/admin/bj/prod/a/rollback?time=1", ""},
+		{"POST", "/v1/admin/bj/prod/a/k1/move?to=k3", ""},
+		{"POST", "/v1/admin/bj/prod/a/move?to=b", ""},
+		{"POST", "/v1/trash/" + trash.ID, ""},
+		{"POST", "/v1/admin/clone", `{"source":{"dc":"bj","env":"prod"},"target":{"dc":"sh","env":"prod"}}`},
+		{"DELETE", "/v1/admin/bj/prod/a/k1", ""},
+		{"DELETE", "/v1/admin/bj/prod/a?purge=true", ""},
+		{"DELETE", "/v1/admin/bj", ""},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected 403, got %d %s", c.method, c.path, w.Code,
+				w.Body.String())
+		}
+	}
+
+	if v, _, err := backend.AppGetConfig("bj", "prod", "a", "k1", 0); err != nil ||
+		v != "v1" {
+		t.Errorf("unexpected value '%s': %v", v, err)
+	}
+}
+
+// failedSetStore is the store, which fails to set the values.
+type failedSetStore struct{ store.Store }
+
+func (s failedSetStore) SetKeyValue(dc, env, app, key, value string) error {
+	return errors.New("failed to set the value")
+}
+
+func TestApproveChange(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "prod")
+	backend.SetKeyValue("bj", "prod", "a", "k1", "v1")
+	c, err := proposeChange("bj", "prod", "a", "k1", "v2", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setProtectedEnvs("prod", 1)
+	defer setProtectedEnvs("", 0)
+	if err = setTrustedProxies("10.0.0.0/8, ::1"); err != nil {
+		t.Fatal(err)
+	}
+	defer setTrustedProxies("")
+
+	approve := func(remoteAddr string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/v1/change/"+c.ID+"/approve", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set(identityHeader, "bob")
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// The identity from the untrusted client is ignored.
+	if code := approve("192.0.2.1:1234"); code != http.StatusUnauthorized {
+		t.Errorf("expected 401, but got %d", code)
+	}
+
+	// The approval is withdrawn if failing to set the value.
+	backend = failedSetStore{backend}
+	if code := approve("10.0.0.1:1234"); code != http.StatusInternalServerError {
+		t.Errorf("expected 500, but got %d", code)
+	}
+	backend = backend.(failedSetStore).Store
+	if c, err := backend.GetChange(c.ID); err != nil ||
+		c.Status != store.ChangePending || len(c.Approvers) != 0 {
+		t.Errorf("unexpected change %+v: %v", c, err)
+	}
+
+	if code := approve("[::1]:1234"); code != http.StatusOK {
+		t.Errorf("expected 200, but got %d", code)
+	}
+	if v, _, _ := backend.AppGetConfig("bj", "prod", "a", "k1", 0); v != "v2" {
+		t.Errorf("expected the approved value 'v2', but got '%s'", v)
+	}
+	if err = setTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Error("expected the error of the invalid proxy")
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// cloneRequest is the request to clone the whole dc and env to a new one.
+//
+// If Apps is empty, clone all the apps. If History is true, clone all
+// the versions of the keys, or only the latest values. If Callbacks is true,
+// clone the callbacks of the keys, the addresses of which are rewritten
+// by Rewrite.
+type cloneRequest struct {
+	Source struct {
+		Dc  string `json:"dc"`
+		Env string `json:"env"`
+	} `json:"source"`
+
+	Target struct {
+		Dc  string `json:"dc"`
+		Env string `json:"env"`
+	} `json:"target"`
+
+	Apps      []string `json:"apps"`
+	History   bool     `json:"history"`
+	Callbacks bool     `json:"callbacks"`
+
+	// Rewrite is the rules to rewrite the addresses of the callbacks,
+	// each of which replaces the substring From with To. The rules are
+	// tried in turn at each position, and the first matched one is used.
+	Rewrite []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"rewrite"`
+}
+
+// CloneConfig clones the apps in the source dc and env to the target,
+// which must have no apps.
+func CloneConfig(w http.ResponseWriter, r *http.Request) error {
+	body, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	var req cloneRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	src, dst := req.Source, req.Target
+	if src.Dc == "" || src.Env == "" {
+		return http2.String(w, http.StatusBadRequest,
+			"missing the source dc or env")
+	} else if dst.Dc == "" || dst.Env == "" {
+		return http2.String(w, http.StatusBadRequest,
+			"missing the target dc or env")
+	} else if src == dst {
+		return http2.String(w, http.StatusBadRequest,
+			"the source is the same as the target")
+	} else if isProtected(dst.Dc, dst.Env) {
+		return renderProtected(w, dst.Dc, dst.Env)
+	}
+
+	pairs := make([]string, 0, len(req.Rewrite)*2)
+	for _, rule := range req.Rewrite {
+		if rule.From == "" {
+			return http2.String(w, http.StatusBadRequest,
+				"the rewrite rule has no from")
+		}
+		pairs = append(pairs, rule.From, rule.To)
+	}
+	rewriter := strings.NewReplacer(pairs...)
+
+	apps := req.Apps
+	if len(apps) == 0 {
+		if apps, err = getAllApps(src.Dc, src.Env); err != nil {
+			return renderError(w, err)
+		}
+	}
+
+	appKeys := make(map[string][]string, len(apps))
+	for _, app := range apps {
+		keys, err := getAllKeys(src.Dc, src.Env, app)
+		if err != nil {
+			return renderError(w, err)
+		}
+		for _, key := range keys {
+			if err = checkKeyName(key); err != nil {
+				return renderError(w, err)
+			}
+		}
+		appKeys[app] = keys
+	}
+
+	// Refuse to overwrite the existed config.
+	existed, err := getAllApps(dst.Dc, dst.Env)
+	if err != nil && err != store.ErrNotFound {
+		return renderError(w, err)
+	} else if len(existed) > 0 {
+		return http2.String(w, http.StatusConflict, "the target is not empty")
+	}
+
+	err = backend.CreateDcAndEnv(dst.Dc, dst.Env)
+	printLog(err, "create dc=%s, env=%s", dst.Dc, dst.Env)
+	if err != nil && err != store.ErrExist {
+		return renderError(w, err)
+	}
+
+	var nkeys, nvalues, ncallbacks int
+	for _, app := range apps {
+		mds, err := backend.GetAllMetadata(src.Dc, src.Env, app)
+		if err != nil {
+			return renderError(w, err)
+		}
+
+		for _, key := range appKeys[app] {
+			var values map[int64]string
+			if req.History {
+				if values, err = getValues(src.Dc, src.Env, app, key, 0); err != nil {
+					return renderError(w, err)
+				}
+			} else {
+				v, t, err := backend.AppGetConfig(src.Dc, src.Env, app, key, 0)
+				if err == store.ErrNotFound {
+					continue
+				} else if err != nil {
+					return renderError(w, err)
+				}
+				values = map[int64]string{t: v}
+			}
+
+			if len(values) == 0 {
+				continue
+			}
+			if err = backend.SetKeyValues(dst.Dc, dst.Env, app, key, values); err != nil {
+				return renderError(w, err)
+			}
+			nkeys++
+			nvalues += len(values)
+
+			if md, ok := mds[key]; ok {
+				md.Dc, md.Env = dst.Dc, dst.Env
+				if _, err = backend.SetMetadata(md); err != nil {
+					return renderError(w, err)
+				}
+			}
+
+			if !req.Callbacks {
+				continue
+			}
+
+			cbs, err := backend.GetCallback(src.Dc, src.Env, app, key)
+			if err == store.ErrNotFound {
+				continue
+			} else if err != nil {
+				return renderError(w, err)
+			}
+			for id, cb := range cbs {
+				err = backend.AddCallback(dst.Dc, dst.Env, app, key, id,
+					rewriter.Replace(cb))
+				if err != nil {
+					return renderError(w, err)
+				}
+				ncallbacks++
+			}
+		}
+	}
+
+	printLog(nil, "Clone dc=%s, env=%s to dc=%s, env=%s: apps=%d, keys=%d",
+		src.Dc, src.Env, dst.Dc, dst.Env, len(apps), nkeys)
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"apps":      len(apps),
+		"keys":      nkeys,
+		"values":    nvalues,
+		"callbacks": ncallbacks,
+	})
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// diffContext is the number of the context lines around the changes
+// in the unified diff.
+const diffContext = 3
+
+// diffMaxEdits is the maximum number of the edits searched from each end
+// when bisecting the lines. Beyond it, the rest of the lines are replaced
+// as a whole, which bounds the time for the large and different values.
+const diffMaxEdits = 1024
+
+// The types of the diff.
+const (
+	diffUnified    = "unified"
+	diffStructural = "structural"
+)
+
+type diffLine struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the line operations to change a to b, which are based on
+// the shortest edit script found by the linear space Myers algorithm, so that
+// the large values do not take the quadratic memory.
+func diffLines(a, b []string) []diffLine {
+	return appendDiffLines(make([]diffLine, 0, len(a)+len(b)), a, b)
+}
+
+// appendDiffLines appends the line operations to change a to b into lines,
+// and returns the extended lines.
+func appendDiffLines(lines []diffLine, a, b []string) []diffLine {
+	// Strip the common prefix and suffix.
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-suffix-1] == b[len(b)-suffix-1] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	common := a[len(a)-suffix:]
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(a) == 0 || len(b) == 0 {
+		for _, line := range a {
+			lines = append(lines, diffLine{'-', line})
+		}
+		for _, line := range b {
+			lines = append(lines, diffLine{'+', line})
+		}
+	} else {
+		x, y := bisectLines(a, b)
+		lines = appendDiffLines(lines, a[:x], b[:y])
+		lines = appendDiffLines(lines, a[x:], b[y:])
+	}
+
+	for _, line := range common {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
+
+// bisectLines finds the middle snake of the shortest edit script from a to b
+// by walking from both ends at the same time, and returns the point to split
+// a and b into two smaller problems.
+//
+// Both a and b must not be empty, and their first and last lines differ.
+func bisectLines(a, b []string) (int, int) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	if maxD > diffMaxEdits {
+		maxD = diffMaxEdits
+	}
+	offset := maxD
+	v1 := make([]int, 2*maxD+2) // The furthest x on the diagonal k from head.
+	v2 := make([]int, 2*maxD+2) // The furthest x on the diagonal k from tail.
+	for i := range v1 {
+		v1[i], v2[i] = -1, -1
+	}
+	v1[offset+1], v2[offset+1] = 0, 0
+
+	// If delta is odd, the forward path overlaps the reverse path first.
+	delta := n - m
+	front := delta%2 != 0
+
+	// Skip the diagonals which have run off the edges.
+	var k1start, k1end, k2start, k2end int
+	for d := 0; d < maxD; d++ {
+		for k1 := -d + k1start; k1 <= d-k1end; k1 += 2 {
+			k1off := offset + k1
+			var x1 int
+			if k1 == -d || (k1 != d && v1[k1off-1] < v1[k1off+1]) {
+				x1 = v1[k1off+1]
+			} else {
+				x1 = v1[k1off-1] + 1
+			}
+			y1 := x1 - k1
+			for x1 < n && y1 < m && a[x1] == b[y1] {
+				x1++
+				y1++
+			}
+			v1[k1off] = x1
+
+			if x1 > n {
+				k1end += 2
+			} else if y1 > m {
+				k1start += 2
+			} else if front {
+				if k2off := offset + delta - k1; k2off >= 0 && k2off < len(v2) &&
+					v2[k2off] != -1 && x1 >= n-v2[k2off] {
+					return x1, y1
+				}
+			}
+		}
+
+		for k2 := -d + k2start; k2 <= d-k2end; k2 += 2 {
+			k2off := offset + k2
+			var x2 int
+			if k2 == -d || (k2 != d && v2[k2off-1] < v2[k2off+1]) {
+				x2 = v2[k2off+1]
+			} else {
+				x2 = v2[k2off-1] + 1
+			}
+			y2 := x2 - k2
+			for x2 < n && y2 < m && a[n-x2-1] == b[m-y2-1] {
+				x2++
+				y2++
+			}
+			v2[k2off] = x2
+
+			if x2 > n {
+				k2end += 2
+			} else if y2 > m {
+				k2start += 2
+			} else if !front {
+				if k1off := offset + delta - k2; k1off >= 0 && k1off < len(v1) &&
+					v1[k1off] != -1 && v1[k1off] >= n-x2 {
+					x1 := v1[k1off]
+					return x1, offset + x1 - k1off
+				}
+			}
+		}
+	}
+
+	// No overlap within diffMaxEdits, so delete all of a, then insert all of b.
+	return n, 0
+}
+
+// unifiedDiff returns the unified diff from a to b, the file names of which
+// in the header are from and to.
+//
+// Return "" if a is the same as b.
+func unifiedDiff(from, to, a, b string) string {
+	lines := diffLines(splitLines(a), splitLines(b))
+
+	// Group the changes into the hunks with the context lines.
+	var hunks [][2]int
+	for i, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+
+		start, end := i-diffContext, i+diffContext+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		if n := len(hunks); n > 0 && start <= hunks[n-1][1] {
+			hunks[n-1][1] = end
+		} else {
+			hunks = append(hunks, [2]int{start, end})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", from, to)
+
+	// aline and bline are the line numbers before lines[pos].
+	var pos, aline, bline int
+	for _, hunk := range hunks {
+		for ; pos < hunk[0]; pos++ {
+			aline++
+			bline++
+		}
+
+		var alen, blen int
+		for _, line := range lines[hunk[0]:hunk[1]] {
+			if line.op != '+' {
+				alen++
+			}
+			if line.op != '-' {
+				blen++
+			}
+		}
+
+		astart, bstart := aline+1, bline+1
+		if alen == 0 {
+			astart = aline
+		}
+		if blen == 0 {
+			bstart = bline
+		}
+		fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", astart, alen, bstart, blen)
+
+		for ; pos < hunk[1]; pos++ {
+			line := lines[pos]
+			buf.WriteByte(line.op)
+			buf.WriteString(line.text)
+			buf.WriteByte('\n')
+			if line.op != '+' {
+				aline++
+			}
+			if line.op != '-' {
+				bline++
+			}
+		}
+	}
+	return buf.String()
+}
+
+// maskDiff replaces the text of the lines in the unified diff with
+// sensitiveMask, but keeps the header and the ranges of the hunks.
+func maskDiff(diff string) string {
+	lines := splitLines(diff)
+	for i := 2; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "@@ ") {
+			lines[i] = lines[i][:1] + sensitiveMask
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// parseStructure parses the value as a JSON or YAML object, and flattens it
+// into the paths joined by ".".
+//
+// Return false if the value is not an object.
+func parseStructure(value string) (map[string]string, bool) {
+	if strings.TrimSpace(value) == "" {
+		return nil, false
+	}
+
+	for _, format := range []string{formatJSON, formatYAML} {
+		if v, err := parseConfig(format, []byte(value)); err == nil {
+			if kvs, err := flattenConfig(v, "."); err == nil {
+				return kvs, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// diffChange is a change of the path in the structural diff.
+type diffChange struct {
+	Path string `json:"path"`
+	Type string `json:"type"` // "added", "removed" or "changed"
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// structuralDiff returns the changes from a to b in the order of the path.
+func structuralDiff(a, b map[string]string) []diffChange {
+	changes := make([]diffChange, 0, 8)
+	for path, old := range a {
+		if v, ok := b[path]; !ok {
+			changes = append(changes, diffChange{Path: path, Type: "removed", Old: old})
+		} else if v != old {
+			changes = append(changes, diffChange{Path: path, Type: "changed",
+				Old: old, New: v})
+		}
+	}
+	for path, v := range b {
+		if _, ok := a[path]; !ok {
+			changes = append(changes, diffChange{Path: path, Type: "added", New: v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// maskChanges replaces the old and new values of the changes with
+// sensitiveMask, but keeps the paths.
+func maskChanges(changes []diffChange) {
+	for i := range changes {
+		if changes[i].Old != "" {
+			changes[i].Old = sensitiveMask
+		}
+		if changes[i].New != "" {
+			changes[i].New = sensitiveMask
+		}
+	}
+}
+
+// DiffKey returns the diff between two versions of the key, which are given
+// by the query arguments from and to.
+//
+// If to is not given, it's the latest version. If from is not given, it's
+// the version before to. If both values are JSON or YAML objects, return
+// the structural diff, or the unified text diff.
+func DiffKey(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	from, err := http2.GetQueryInt64(query, "from")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	to, err := http2.GetQueryInt64(query, "to")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	_type := http2.GetQuery(query, "type")
+	if _type != "" && _type != diffUnified && _type != diffStructural {
+		return http2.String(w, http.StatusBadRequest,
+			"the type must be unified or structural")
+	}
+
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+
+	b, to, err := backend.AppGetConfig(dc, env, app, key, to)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	if from < 1 {
+		versions, err := getVersions(dc, env, app, key, to)
+		if err != nil {
+			return renderError(w, err)
+		} else if len(versions) < 2 {
+			return http2.String(w, http.StatusNotFound, "no previous version")
+		}
+		from = versions[len(versions)-2]
+	}
+
+	a, _, err := backend.AppGetConfig(dc, env, app, key, from)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	sensitive, err := isSensitive(dc, env, app, key)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	result := map[string]interface{}{"from": from, "to": to}
+	if _type != diffUnified {
+		sa, oka := parseStructure(a)
+		sb, okb := parseStructure(b)
+		if oka && okb {
+			changes := structuralDiff(sa, sb)
+			if sensitive {
+				maskChanges(changes)
+			}
+			result["type"] = diffStructural
+			result["changes"] = changes
+			return http2.JSON(w, http.StatusOK, result)
+		} else if _type == diffStructural {
+			return http2.String(w, http.StatusBadRequest,
+				"the values are not JSON or YAML objects")
+		}
+	}
+
+	diff := unifiedDiff(fmt.Sprintf("%s@%d", key, from),
+		fmt.Sprintf("%s@%d", key, to), a, b)
+	if sensitive {
+		diff = maskDiff(diff)
+	}
+	result["type"] = diffUnified
+	result["diff"] = diff
+	return http2.JSON(w, http.StatusOK, result)
+}
+
+// DiffApp compares the latest values of the keys of the app with those
+// of the app in another dc and env, which is given by the query argument
+// against, the format of which is "dc/env" or "dc/env/app".
+func DiffApp(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+
+	against := http2.GetQuery(r.URL.Query(), "against")
+	ss := strings.Split(against, "/")
+	if len(ss) < 2 || len(ss) > 3 || ss[0] == "" || ss[1] == "" {
+		return http2.String(w, http.StatusBadRequest,
+			"the format of against must be dc/env or dc/env/app")
+	}
+	if len(ss) == 2 {
+		ss = append(ss, app)
+	}
+
+	self, _, err := getAppConfig(dc, env, app)
+	if err != nil && err != store.ErrNotFound {
+		return renderError(w, err)
+	}
+	other, _, err := getAppConfig(ss[0], ss[1], ss[2])
+	if err != nil && err != store.ErrNotFound {
+		return renderError(w, err)
+	}
+	if len(self) == 0 && len(other) == 0 {
+		return renderError(w, store.ErrNotFound)
+	}
+
+	onlySelf := make([]string, 0, len(self))
+	different := make([]string, 0, len(self))
+	same := make([]string, 0, len(self))
+	for _, key := range sortedKeys(self) {
+		if v, ok := other[key]; !ok {
+			onlySelf = append(onlySelf, key)
+		} else if v != self[key] {
+			different = append(different, key)
+		} else {
+			same = append(same, key)
+		}
+	}
+
+	onlyAgainst := make([]string, 0, len(other))
+	for _, key := range sortedKeys(other) {
+		if _, ok := self[key]; !ok {
+			onlyAgainst = append(onlyAgainst, key)
+		}
+	}
+
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"against":      strings.Join(ss, "/"),
+		"only_self":    onlySelf,
+		"only_against": onlyAgainst,
+		"different":    different,
+		"same":         same,
+	})
+}
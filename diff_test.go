@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\n"
+	expected := "--- k@1\n+++ k@2\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -7,3 +7,4 @@\n g\n h\n i\n+j\n"
+	if diff := unifiedDiff("k@1", "k@2", a, b); diff != expected {
+		t.Errorf("expected %q, got %q", expected, diff)
+	}
+
+	if diff := unifiedDiff("k@1", "k@2", a, a); diff != "" {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+
+	expected = "--- k@1\n+++ k@2\n@@ -0,0 +1,1 @@\n+x\n"
+	if diff := unifiedDiff("k@1", "k@2", "", "x"); diff != expected {
+		t.Errorf("expected %q, got %q", expected, diff)
+	}
+}
+
+func TestDiffLargeLines(t *testing.T) {
+	a := make([]string, 100000)
+	b := make([]string, len(a))
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+		b[i] = strconv.Itoa(-i - 1)
+	}
+
+	// The different lines are replaced as a whole.
+	lines := diffLines(a, b)
+	if len(lines) != len(a)+len(b) {
+		t.Fatalf("expected %d lines, got %d", len(a)+len(b), len(lines))
+	}
+	for i, line := range lines {
+		if (i < len(a) && line.op != '-') || (i >= len(a) && line.op != '+') {
+			t.Fatalf("unexpected line %d: %c%s", i, line.op, line.text)
+		}
+	}
+
+	b = append(append([]string{}, a[:50000]...), "x")
+	b = append(b, a[50001:]...)
+	expected := "--- k@1\n+++ k@2\n@@ -49998,7 +49998,7 @@\n" +
+		" 49997\n 49998\n 49999\n-50000\n+x\n 50001\n 50002\n 50003\n"
+	diff := unifiedDiff("k@1", "k@2", strings.Join(a, "\n"), strings.Join(b, "\n"))
+	if diff != expected {
+		t.Errorf("expected %q, got %q", expected, diff)
+	}
+}
+
+func TestStructuralDiff(t *testing.T) {
+	a, ok := parseStructure(`{"db": {"host": "a", "port": 3306}, "x": 1}`)
+	if !ok {
+		t.Fatal("expect a JSON object")
+	}
+	b, ok := parseStructure("db:\n  host: b\n  port: 3306\nz: 2\n")
+	if !ok {
+		t.Fatal("expect a YAML object")
+	}
+	if _, ok := parseStructure("plain text"); ok {
+		t.Error("expect not an object")
+	}
+
+	changes := structuralDiff(a, b)
+	expected := []diffChange{
+		{Path: "db.host", Type: "changed", Old: "a", New: "b"},
+		{Path: "x", Type: "removed", Old: "1"},
+		{Path: "z", Type: "added", New: "2"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, changes)
+	}
+	for i := range changes {
+		if changes[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], changes[i])
+		}
+	}
+}
@@ -37,3 +37,141 @@ CREATE TABLE `appresult` (
 
     PRIMARY KEY (`id`)
 )
+
+
+CREATE TABLE `appevent` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL DEFAULT 0 COMMENT 'The version of the value to be set or deleted',
+    `value` TEXT DEFAULT NULL COMMENT 'The value to be set',
+    `deleted` TINYINT NOT NULL DEFAULT 0 COMMENT 'Whether the config is deleted',
+
+    PRIMARY KEY (`id`)
+)
+
+
+CREATE TABLE `approllback` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL COMMENT 'The new version set by the rollback',
+    `target` INTEGER NOT NULL COMMENT 'The previous version rolled back to',
+
+    PRIMARY KEY (`id`)
+)
+
+
+CREATE TABLE `apptrash` (
+    `id` VARCHAR(32) NOT NULL COMMENT 'The id of the trash',
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL DEFAULT '' COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL DEFAULT '' COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the config is deleted',
+    `data` LONGTEXT NOT NULL COMMENT 'The rows of the config, callback, result, rollback, metadata, drafts, canaries, schedules and tags, as JSON',
+
+    PRIMARY KEY (`id`)
+)
+
+
+CREATE TABLE `apptag` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `name` VARCHAR(64) NOT NULL COMMENT 'The name of the tag',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the tag is created',
+    `versions` TEXT NOT NULL COMMENT 'The versions of the keys, as JSON',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`dc`, `env`, `app`, `name`)
+)
+
+
+CREATE TABLE `appdraft` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the draft is saved',
+    `value` TEXT DEFAULT NULL COMMENT 'The pending value of the key',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`dc`, `env`, `app`, `key`)
+)
+
+
+CREATE TABLE `appchange` (
+    `id` VARCHAR(32) NOT NULL COMMENT 'The id of the change request',
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `status` VARCHAR(16) NOT NULL COMMENT 'The status, such as pending, approved or rejected',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the change is proposed',
+    `revision` INTEGER NOT NULL COMMENT 'The revision increased by each update',
+    `data` TEXT NOT NULL COMMENT 'The whole change request, as JSON',
+
+    PRIMARY KEY (`id`)
+)
+
+
+CREATE TABLE `appschedule` (
+    `id` VARCHAR(32) NOT NULL COMMENT 'The id of the schedule',
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL COMMENT 'The name of the key of app',
+    `value` TEXT DEFAULT NULL COMMENT 'The value of the key to be set',
+    `at` INTEGER NOT NULL COMMENT 'The unixstamp time when the value is set',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when scheduled',
+
+    PRIMARY KEY (`id`),
+    KEY (`at`)
+)
+
+
+CREATE TABLE `appcanary` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the canary is updated',
+    `data` TEXT NOT NULL COMMENT 'The candidate value and the rules, as JSON',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`dc`, `env`, `app`, `key`)
+)
+
+
+CREATE TABLE `appdependency` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `namespace` VARCHAR(32) NOT NULL COMMENT 'The name of the namespace, on which the app depends',
+    `priority` INTEGER NOT NULL COMMENT 'The priority of the namespace, the less the higher',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`dc`, `env`, `app`, `namespace`),
+    KEY (`dc`, `env`, `namespace`)
+)
+
+
+CREATE TABLE `appmetadata` (
+    `id` INTEGER NOT NULL AUTO_INCREMENT,
+    `dc` VARCHAR(32) NOT NULL COMMENT 'The name of the Data Center',
+    `env` VARCHAR(32) NOT NULL COMMENT 'The name of the environment in DC',
+    `app` VARCHAR(32) NOT NULL COMMENT 'The name of the application',
+    `key` VARCHAR(64) NOT NULL COMMENT 'The name of the key of app',
+    `time` INTEGER NOT NULL COMMENT 'The unixstamp time when the metadata is updated',
+    `data` TEXT NOT NULL COMMENT 'The description, the owner, the labels, the content type and the sensitivity, as JSON',
+
+    PRIMARY KEY (`id`),
+    UNIQUE KEY (`dc`, `env`, `app`, `key`)
+)
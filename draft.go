@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// reservedKey is the name of the key, which cannot be uploaded, because
+// its path is used by the route publishing the drafts of the app.
+const reservedKey = "publish"
+
+// checkKeyName returns an error, rendered as 400, if the key is reserved.
+func checkKeyName(key string) error {
+	if key == reservedKey {
+		return badRequestError("the key named '%s' is reserved", key)
+	}
+	return nil
+}
+
+// PublishDrafts publishes all the drafts of the app as one change set,
+// then notifies the callbacks of each published key once.
+func PublishDrafts(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	published, err := backend.PublishDrafts(dc, env, app)
+	printLog(err, "Publish the drafts: dc=%s, env=%s, app=%s", dc, env, app)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	var version int64
+	keys := make([]string, 0, len(published))
+	for key, v := range published {
+		keys = append(keys, key)
+		version = v.Time
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		err = notifyCallbacks(dc, env, app, key, published[key].Value)
+		if err != nil {
+			return renderError(w, err)
+		}
+	}
+
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"version": version,
+		"keys":    keys,
+	})
+}
+
+// GetDrafts returns all the drafts of the app.
+func GetDrafts(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	drafts, err := backend.GetDrafts(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if drafts == nil {
+		drafts = []store.Draft{}
+	}
+	for i, d := range drafts {
+		if drafts[i].Value, err = maskValue(vs["dc"], vs["env"], vs["app"], d.Key,
+			d.Value); err != nil {
+			return renderError(w, err)
+		}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"drafts": drafts})
+}
+
+// DiscardDrafts discards the draft of the key, or all the drafts of the app.
+func DiscardDrafts(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	err := backend.DeleteDrafts(vs["dc"], vs["env"], vs["app"], vs["key"])
+	printLog(err, "Discard the drafts: dc=%s, env=%s, app=%s, key=%s", vs["dc"],
+		vs["env"], vs["app"], vs["key"])
+	return renderError(w, err)
+}
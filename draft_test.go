@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestReservedKey(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v1")
+	backend.SetDraft("bj", "dev", "a", "k1", "v2")
+
+	// The key created before reserved cannot be copied.
+	backend.CreateDcAndEnv("bj", "old")
+	backend.SetKeyValue("bj", "old", "a", reservedKey, "v1")
+
+	// The path of the key publish is routed to publish the drafts.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/v1/admin/bj/dev/a/publish",
+		strings.NewReader("v3"))
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("publish: expected 200, but got %d: %s", w.Code, w.Body)
+	}
+	if v, _, _ := backend.AppGetConfig("bj", "dev", "a", "k1", 0); v != "v2" {
+		t.Errorf("expected the published draft 'v2', but got '%s'", v)
+	}
+	if _, _, err := backend.AppGetConfig("bj", "dev", "a", reservedKey, 0); err !=
+		store.ErrNotFound {
+		t.Errorf("expected no key '%s', but got %v", reservedKey, err)
+	}
+
+	cases := []struct{ path, body string }{
+		{"/v1/admin/bj/dev/a?format=json", `{"publish": "v1"}`},
+		{"/v1/admin/bj/dev/a/k1/move?to=publish", ""},
+		{"/v1/admin/bj/dev/a/k1/move?to=b/publish", ""},
+		{"/v1/admin/clone", `{"source":{"dc":"bj","env":"old"},"target":{"dc":"sh","env":"dev"}}`},
+		{"/v1/admin/promote", `{"source":{"dc":"bj","env":"old","app":"a"},"target":{"dc":"bj","env":"dev"}}`},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", c.path, strings.NewReader(c.body))
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, but got %d", c.path, w.Code)
+		}
+	}
+
+	_, _, err := uploadConfig("bj", "dev", "a", reservedKey, "v1", false, 0, "")
+	if err == nil {
+		t.Errorf("expected the error to upload the key '%s'", reservedKey)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// levelHeader is the response header of the level serving the value.
+const levelHeader = "X-Appconfig-Level"
+
+// The levels of the fallback chain.
+const (
+	levelEnv    = "env"
+	levelDc     = "dc"
+	levelGlobal = "global"
+)
+
+var (
+	// defaultEnv is the env in the same dc, to which the lookups fall back.
+	// If empty, disable it.
+	defaultEnv = "_default"
+
+	// defaultDc is the global default dc, to the env defaultEnv of which
+	// the lookups fall back finally. If empty, disable it.
+	defaultDc = "_default"
+)
+
+// setFallback sets the default dc and env of the fallback chain.
+func setFallback(dc, env string) {
+	defaultDc = dc
+	defaultEnv = env
+}
+
+// fallbackLevel is a level of the fallback chain.
+type fallbackLevel struct {
+	Level string
+	Dc    string
+	Env   string
+}
+
+// getFallbackChain returns the levels looked up in turn for env in dc.
+func getFallbackChain(dc, env string) []fallbackLevel {
+	chain := []fallbackLevel{{Level: levelEnv, Dc: dc, Env: env}}
+	if defaultEnv == "" {
+		return chain
+	}
+
+	if env != defaultEnv {
+		chain = append(chain, fallbackLevel{Level: levelDc, Dc: dc, Env: defaultEnv})
+	}
+	if defaultDc != "" && dc != defaultDc {
+		chain = append(chain, fallbackLevel{Level: levelGlobal, Dc: defaultDc,
+			Env: defaultEnv})
+	}
+	return chain
+}
+
+// getLatestValue returns the latest value of the key like AppGetConfig,
+// but falls back to the default env in the same dc, then the global default
+// dc, if the key does not exist. level is the level serving the value.
+func getLatestValue(dc, env, app, key string) (v string, version int64,
+	level string, err error) {
+	for _, l := range getFallbackChain(dc, env) {
+		v, version, err = backend.AppGetConfig(l.Dc, l.Env, app, key, 0)
+		if err != store.ErrNotFound {
+			return v, version, l.Level, err
+		}
+	}
+	return
+}
+
+// getLevelConfigs returns the latest values of all the keys of the app
+// at each level of the fallback chain, and the newest version of them.
+func getLevelConfigs(dc, env, app string) ([]map[string]string, int64,
+	error) {
+	var version int64
+	chain := getFallbackChain(dc, env)
+	levels := make([]map[string]string, len(chain))
+	for i, l := range chain {
+		kvs, v, err := getAppConfig(l.Dc, l.Env, app)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		levels[i] = kvs
+		if v > version {
+			version = v
+		}
+	}
+	return levels, version, nil
+}
+
+// getInheritedConfig returns the latest values of all the keys of the app
+// like getAppConfig, including the keys inherited by the fallback chain.
+func getInheritedConfig(dc, env, app string) (map[string]string, int64,
+	error) {
+	levels, version, err := getLevelConfigs(dc, env, app)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	kvs := make(map[string]string, 32)
+	for i := len(levels) - 1; i >= 0; i-- {
+		for k, v := range levels[i] {
+			kvs[k] = v
+		}
+	}
+	if len(kvs) == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+	return kvs, version, nil
+}
+
+// notifyInheritors notifies the callbacks of the key in the envs, which
+// inherit the value of the key in env of dc by the fallback chain.
+func notifyInheritors(dc, env, app, key, value string) error {
+	if env != defaultEnv {
+		return nil
+	}
+
+	dcs, err := backend.GetAllDcAndEnvs()
+	if err != nil {
+		return err
+	}
+
+	for _dc, envs := range dcs {
+		for _, _env := range envs {
+			chain := getFallbackChain(_dc, _env)
+			for i := 1; i < len(chain); i++ {
+				if chain[i].Dc != dc || chain[i].Env != env {
+					continue
+				}
+
+				// Only if the key does not exist at the levels before it.
+				_, _, level, err := getLatestValue(_dc, _env, app, key)
+				if err != nil {
+					return err
+				} else if level != chain[i].Level {
+					break
+				}
+
+				if err = notifyKey(_dc, _env, app, key, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// inheritedConfig is the values of a key at each level of the fallback chain.
+type inheritedConfig struct {
+	Key    string            `json:"key"`
+	Value  string            `json:"value"`
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels"`
+}
+
+// getInheritedKeys returns the keys of the app, including the inherited keys,
+// with the effective value and the values at each level.
+func getInheritedKeys(w http.ResponseWriter, r *http.Request, search string,
+	page, size int64) error {
+	vs := mux.Vars(r)
+	chain := getFallbackChain(vs["dc"], vs["env"])
+	levels, _, err := getLevelConfigs(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	configs := make(map[string]*inheritedConfig, 32)
+	for i, kvs := range levels {
+		for k, v := range kvs {
+			if search != "" && !strings.Contains(k, search) {
+				continue
+			}
+
+			c, ok := configs[k]
+			if !ok {
+				c = &inheritedConfig{Key: k, Value: v, Level: chain[i].Level,
+					Levels: make(map[string]string, len(chain))}
+				configs[k] = c
+			}
+			c.Levels[chain[i].Level] = v
+		}
+	}
+
+	keys := make([]string, 0, len(configs))
+	for k := range configs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	total := int64(len(keys))
+	start, end := (page-1)*size, page*size
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	keys = keys[start:end]
+
+	results := make([]*inheritedConfig, len(keys))
+	for i, k := range keys {
+		results[i] = configs[k]
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"total": total,
+		"keys": keys, "configs": results})
+}
@@ -0,0 +1,583 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// The formats of the whole configuration of an app.
+const (
+	formatJSON       = "json"
+	formatYAML       = "yaml"
+	formatTOML       = "toml"
+	formatEnv        = "env"
+	formatProperties = "properties"
+)
+
+// formatContentTypes is the Content-Type of the response for each format.
+var formatContentTypes = map[string]string{
+	formatJSON:       "application/json; charset=utf-8",
+	formatYAML:       "application/x-yaml; charset=utf-8",
+	formatTOML:       "application/toml; charset=utf-8",
+	formatEnv:        "text/plain; charset=utf-8",
+	formatProperties: "text/x-java-properties; charset=iso-8859-1",
+}
+
+// formatMediaTypes maps the media types in the header Accept to the formats.
+var formatMediaTypes = map[string]string{
+	"application/json":       formatJSON,
+	"application/x-yaml":     formatYAML,
+	"application/yaml":       formatYAML,
+	"text/yaml":              formatYAML,
+	"text/x-yaml":            formatYAML,
+	"application/toml":       formatTOML,
+	"application/x-toml":     formatTOML,
+	"text/x-dotenv":          formatEnv,
+	"text/x-java-properties": formatProperties,
+}
+
+// getFormat returns the format of the whole configuration of an app.
+//
+// The query argument format has a higher priority than the header Accept.
+// If neither of them is given, it's JSON by default.
+func getFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", fmt.Errorf("not support the format '%s'", format)
+		}
+		return format, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return formatJSON, nil
+	}
+
+	var format string
+	var quality float64
+	for _, s := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= quality {
+			continue
+		}
+
+		if mt == "*/*" || mt == "application/*" {
+			format, quality = formatJSON, q
+		} else if f, ok := formatMediaTypes[mt]; ok {
+			format, quality = f, q
+		}
+	}
+
+	if format == "" {
+		return "", fmt.Errorf("not support the media types '%s'", accept)
+	}
+	return format, nil
+}
+
+// expandKeys expands the dotted names of the keys into the nested objects.
+//
+// For example, {"db.host": "127.0.0.1", "db.port": "3306"} is expanded to
+// {"db": {"host": "127.0.0.1", "port": "3306"}}.
+func expandKeys(kvs map[string]string, sep string) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(kvs))
+	for _, key := range sortedKeys(kvs) {
+		parts := strings.Split(key, sep)
+		last := len(parts) - 1
+
+		m := result
+		for i, part := range parts[:last] {
+			switch v := m[part].(type) {
+			case nil:
+				sub := make(map[string]interface{})
+				m[part] = sub
+				m = sub
+			case map[string]interface{}:
+				m = v
+			default:
+				return nil, fmt.Errorf("the key '%s' conflicts with '%s'", key,
+					strings.Join(parts[:i+1], sep))
+			}
+		}
+
+		if _, ok := m[parts[last]]; ok {
+			return nil, fmt.Errorf("the key '%s' conflicts with the nested keys",
+				key)
+		}
+		m[parts[last]] = kvs[key]
+	}
+	return result, nil
+}
+
+// renderConfig renders the key-values of an app as a whole document.
+//
+// If nested is true, the dotted names of the keys are expanded into the nested
+// objects, which is ignored by the flat formats, env and properties.
+func renderConfig(format string, kvs map[string]string, nested bool) (
+	[]byte, error) {
+
+	var v interface{} = kvs
+	if nested && format != formatEnv && format != formatProperties {
+		m, err := expandKeys(kvs, ".")
+		if err != nil {
+			return nil, err
+		}
+		v = m
+	}
+
+	switch format {
+	case formatJSON:
+		return json.Marshal(v)
+	case formatYAML:
+		return yaml.Marshal(v)
+	case formatTOML:
+		buf := bytes.NewBuffer(nil)
+		if err := toml.NewEncoder(buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case formatEnv:
+		return renderEnv(kvs)
+	case formatProperties:
+		return renderProperties(kvs), nil
+	default:
+		return nil, fmt.Errorf("not support the format '%s'", format)
+	}
+}
+
+func sortedKeys(kvs map[string]string) []string {
+	keys := make([]string, 0, len(kvs))
+	for key := range kvs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// envName converts the name of the key to the name of the environment
+// variable, which only contains the characters in [A-Za-z0-9_].
+func envName(key string) string {
+	bs := []byte(key)
+	for i, c := range bs {
+		if !(c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') ||
+			('A' <= c && c <= 'Z')) {
+			bs[i] = '_'
+		}
+	}
+	if len(bs) == 0 || ('0' <= bs[0] && bs[0] <= '9') {
+		return "_" + string(bs)
+	}
+	return string(bs)
+}
+
+var envValueReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"$", `\$`,
+	"`", "\\`",
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// renderEnv renders the key-values as the dotenv file, each line of which is
+// NAME="VALUE".
+func renderEnv(kvs map[string]string) ([]byte, error) {
+	names := make(map[string]string, len(kvs))
+	buf := bytes.NewBuffer(nil)
+	for _, key := range sortedKeys(kvs) {
+		name := envName(key)
+		if k, ok := names[name]; ok {
+			return nil, fmt.Errorf("the keys '%s' and '%s' have the same name '%s'",
+				k, key, name)
+		}
+		names[name] = key
+
+		fmt.Fprintf(buf, "%s=\"%s\"\n", name, envValueReplacer.Replace(kvs[key]))
+	}
+	return buf.Bytes(), nil
+}
+
+// escapeProperty escapes the key or the value of the Java properties file,
+// which is the same as java.util.Properties#store.
+func escapeProperty(s string, isKey bool) string {
+	buf := bytes.NewBuffer(make([]byte, 0, len(s)))
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if i == 0 || isKey {
+				buf.WriteByte('\\')
+			}
+			buf.WriteByte(' ')
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\\', '=', ':', '#', '!':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, c := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(buf, `\u%04X`, c)
+				}
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// renderProperties renders the key-values as the Java properties file.
+func renderProperties(kvs map[string]string) []byte {
+	buf := bytes.NewBuffer(nil)
+	for _, key := range sortedKeys(kvs) {
+		buf.WriteString(escapeProperty(key, true))
+		buf.WriteByte('=')
+		buf.WriteString(escapeProperty(kvs[key], false))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// getImportFormat returns the format of the uploaded configuration file.
+//
+// The query argument format has a higher priority than the header
+// Content-Type. If neither of them is given, it's JSON by default.
+func getImportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := formatContentTypes[format]; !ok {
+			return "", fmt.Errorf("not support the format '%s'", format)
+		}
+		return format, nil
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if ct == "" {
+		return formatJSON, nil
+	}
+
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", err
+	}
+	if format, ok := formatMediaTypes[mt]; ok {
+		return format, nil
+	}
+	return "", fmt.Errorf("not support the Content-Type '%s'", ct)
+}
+
+// parseConfig parses the configuration file in the format.
+func parseConfig(format string, data []byte) (map[string]interface{}, error) {
+	switch format {
+	case formatJSON:
+		var v map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case formatYAML:
+		var v map[interface{}]interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		return normalizeYAML(v).(map[string]interface{}), nil
+	case formatTOML:
+		var v map[string]interface{}
+		if _, err := toml.Decode(string(data), &v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case formatEnv:
+		return parseEnv(data)
+	case formatProperties:
+		return parseProperties(data)
+	default:
+		return nil, fmt.Errorf("not support the format '%s'", format)
+	}
+}
+
+// normalizeYAML converts map[interface{}]interface{} decoded by YAML
+// to map[string]interface{} recursively.
+func normalizeYAML(v interface{}) interface{} {
+	switch x := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = normalizeYAML(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range x {
+			x[i] = normalizeYAML(v)
+		}
+		return x
+	default:
+		return v
+	}
+}
+
+// flattenConfig flattens the nested structures into the keys, the names of
+// which are joined by sep.
+//
+// The scalar values are converted to the strings, and the arrays are encoded
+// as JSON.
+func flattenConfig(v map[string]interface{}, sep string) (map[string]string,
+	error) {
+
+	kvs := make(map[string]string, len(v))
+	if err := flatten(kvs, "", sep, v); err != nil {
+		return nil, err
+	}
+	return kvs, nil
+}
+
+func flatten(kvs map[string]string, prefix, sep string, v map[string]interface{}) error {
+	for k, v := range v {
+		key := k
+		if prefix != "" {
+			key = prefix + sep + k
+		}
+
+		if m, ok := v.(map[string]interface{}); ok {
+			if err := flatten(kvs, key, sep, m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		value, err := toValue(v)
+		if err != nil {
+			return err
+		}
+		if _, ok := kvs[key]; ok {
+			return fmt.Errorf("the key '%s' is duplicate", key)
+		}
+		kvs[key] = value
+	}
+	return nil
+}
+
+func toValue(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return x, nil
+	case json.Number:
+		return x.String(), nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case time.Time:
+		return x.Format(time.RFC3339Nano), nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(x), nil
+	default:
+		data, err := json.Marshal(x)
+		return string(data), err
+	}
+}
+
+// parseEnv parses the dotenv file, each line of which is NAME=VALUE.
+//
+// The value may be quoted by the double quotation marks, in which the escaped
+// characters are supported, or the single quotation marks, in which the value
+// is literal.
+func parseEnv(data []byte) (map[string]interface{}, error) {
+	v := make(map[string]interface{})
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		index := strings.IndexByte(line, '=')
+		if index < 1 {
+			return nil, fmt.Errorf("line %d: missing the name or '='", i+1)
+		}
+		name := strings.TrimSpace(line[:index])
+		value, err := parseEnvValue(strings.TrimSpace(line[index+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		v[name] = value
+	}
+	return v, nil
+}
+
+func parseEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+
+	switch s[0] {
+	case '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("missing the closing quotation mark")
+		}
+		return s[1 : end+1], nil
+	case '"':
+		buf := bytes.NewBuffer(make([]byte, 0, len(s)))
+		for i := 1; i < len(s); i++ {
+			switch c := s[i]; c {
+			case '"':
+				return buf.String(), nil
+			case '\\':
+				if i++; i == len(s) {
+					return "", fmt.Errorf("missing the closing quotation mark")
+				}
+				switch s[i] {
+				case 'n':
+					buf.WriteByte('\n')
+				case 'r':
+					buf.WriteByte('\r')
+				case 't':
+					buf.WriteByte('\t')
+				default:
+					buf.WriteByte(s[i])
+				}
+			default:
+				buf.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("missing the closing quotation mark")
+	default:
+		if index := strings.Index(s, " #"); index > -1 {
+			s = s[:index]
+		}
+		return strings.TrimSpace(s), nil
+	}
+}
+
+// parseProperties parses the Java properties file, which is the same as
+// java.util.Properties#load.
+func parseProperties(data []byte) (map[string]interface{}, error) {
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	lines := strings.Split(strings.Replace(text, "\r", "\n", -1), "\n")
+
+	v := make(map[string]interface{})
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Join the continuation lines, which end with an odd number of '\'.
+		for endsWithBackslash(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if endsWithBackslash(line) {
+			line = line[:len(line)-1]
+		}
+
+		// Find the separator between the key and the value.
+		end := len(line)
+		for j := 0; j < len(line); j++ {
+			if c := line[j]; c == '\\' {
+				j++
+			} else if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+				end = j
+				break
+			}
+		}
+
+		value := strings.TrimLeft(line[end:], " \t\f")
+		if value != "" && (value[0] == '=' || value[0] == ':') {
+			value = strings.TrimLeft(value[1:], " \t\f")
+		}
+
+		key, err := unescapeProperty(line[:end])
+		if err != nil {
+			return nil, err
+		}
+		if v[key], err = unescapeProperty(value); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+func endsWithBackslash(s string) bool {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	units := make([]uint16, 0, len(s))
+	for i := 0; i < len(s); {
+		if s[i] != '\\' {
+			r, n := utf8.DecodeRuneInString(s[i:])
+			units = append(units, utf16.Encode([]rune{r})...)
+			i += n
+			continue
+		}
+
+		if i++; i == len(s) {
+			break
+		}
+		switch c := s[i]; c {
+		case 't':
+			units = append(units, '\t')
+		case 'n':
+			units = append(units, '\n')
+		case 'r':
+			units = append(units, '\r')
+		case 'f':
+			units = append(units, '\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\uxxxx encoding: %s", s)
+			}
+			u, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uxxxx encoding: %s", s)
+			}
+			units = append(units, uint16(u))
+			i += 4
+		default:
+			r, n := utf8.DecodeRuneInString(s[i:])
+			units = append(units, utf16.Encode([]rune{r})...)
+			i += n
+			continue
+		}
+		i++
+	}
+	return string(utf16.Decode(units)), nil
+}
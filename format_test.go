@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestExpandKeys(t *testing.T) {
+	kvs := map[string]string{"db.host": "127.0.0.1", "db.port": "3306", "a": "b"}
+	v, err := expandKeys(kvs, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db, ok := v["db"].(map[string]interface{}); !ok || db["port"] != "3306" {
+		t.Errorf("unexpected result: %v", v)
+	}
+
+	kvs = map[string]string{"db": "mysql", "db.host": "127.0.0.1"}
+	if _, err := expandKeys(kvs, "."); err == nil {
+		t.Error("expect a conflict error")
+	}
+}
+
+func TestRenderFlatFormats(t *testing.T) {
+	kvs := map[string]string{"db.host": "a b", "x": "say \"hi\"\n$HOME", "中": "文"}
+
+	env, err := renderEnv(kvs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "db_host=\"a b\"\nx=\"say \\\"hi\\\"\\n\\$HOME\"\n___=\"文\"\n"
+	if string(env) != expected {
+		t.Errorf("expected %q, got %q", expected, env)
+	}
+
+	props := renderProperties(kvs)
+	expected = "db.host=a b\nx=say \"hi\"\\n$HOME\n\\u4E2D=\\u6587\n"
+	if string(props) != expected {
+		t.Errorf("expected %q, got %q", expected, props)
+	}
+}
+
+func TestParseFlatFormats(t *testing.T) {
+	env := "# comment\nexport A=\"x \\\"y\\\"\\n\"\nB='$raw' \nC= plain # comment\n"
+	v, err := parseEnv([]byte(env))
+	if err != nil {
+		t.Fatal(err)
+	} else if v["A"] != "x \"y\"\n" || v["B"] != "$raw" || v["C"] != "plain" {
+		t.Errorf("unexpected result: %q", v)
+	}
+
+	props := "! comment\n  a\\ b = 1\\\n    2\nc:\\u4E2D\nd e\n"
+	if v, err = parseProperties([]byte(props)); err != nil {
+		t.Fatal(err)
+	} else if v["a b"] != "12" || v["c"] != "中" || v["d"] != "e" {
+		t.Errorf("unexpected result: %q", v)
+	}
+}
+
+func TestFlattenConfig(t *testing.T) {
+	v, err := parseConfig(formatYAML, []byte("db:\n  host: h\n  port: 3306\nlist: [1, 2]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kvs, err := flattenConfig(v, "_")
+	if err != nil {
+		t.Fatal(err)
+	} else if kvs["db_host"] != "h" || kvs["db_port"] != "3306" || kvs["list"] != "[1,2]" {
+		t.Errorf("unexpected result: %q", kvs)
+	}
+}
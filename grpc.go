@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/xgfone/appconfig/rpc"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/lifecycle"
+	"github.com/xgfone/go-tools/net2/http2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer is the implementation of the gRPC service, which shares
+// the backend store, the callback notification and the logic of the config
+// with the HTTP handlers.
+type grpcServer struct {
+	rpc.UnimplementedAppConfigServer
+}
+
+// toStatus converts the error of the backend store to the gRPC status error.
+func toStatus(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case store.ErrExist:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case store.ErrNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case store.ErrNoDcAndEnv:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case store.ErrConflict:
+		return status.Error(codes.Aborted, err.Error())
+	}
+
+	if e, ok := err.(http2.HTTPError); ok {
+		switch e.Code {
+		case http.StatusBadRequest:
+			return status.Error(codes.InvalidArgument, e.Error())
+		case http.StatusUnauthorized:
+			return status.Error(codes.Unauthenticated, e.Error())
+		case http.StatusForbidden:
+			return status.Error(codes.PermissionDenied, e.Error())
+		case http.StatusNotFound:
+			return status.Error(codes.NotFound, e.Error())
+		default:
+			// The values cannot be resolved, or the versions are pinned
+			// by the tags.
+			return status.Error(codes.FailedPrecondition, e.Error())
+		}
+	}
+
+	logger.Errorf("Get an error: %s", err)
+	return status.Error(codes.Internal, err.Error())
+}
+
+// getMetadata returns the first value of the metadata name of the request,
+// or "" if it does not exist.
+func getMetadata(ctx context.Context, name string) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vs := md.Get(name); len(vs) > 0 {
+			return vs[0]
+		}
+	}
+	return ""
+}
+
+// getGRPCClient returns the client of the app calling the gRPC service,
+// the instance ID of which is the metadata like the header instanceHeader.
+func getGRPCClient(ctx context.Context) configClient {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		var err error
+		if ip, _, err = net.SplitHostPort(p.Addr.String()); err != nil {
+			ip = p.Addr.String()
+		}
+	}
+	return configClient{IP: ip, Instance: getMetadata(ctx, instanceHeader)}
+}
+
+// getGRPCUser returns the identity of the user calling the gRPC service,
+// which is the metadata like the header identityHeader set by a trusted proxy.
+func getGRPCUser(ctx context.Context) string {
+	return getIdentity(getGRPCClient(ctx).IP, getMetadata(ctx, identityHeader))
+}
+
+// getPage returns the page and the size of the list request, which are
+// 1 and 20 by default.
+func getPage(in *rpc.ListRequest) (page, size int64) {
+	if page = in.Page; page < 1 {
+		page = 1
+	}
+	if size = in.Size; size < 1 {
+		size = 20
+	}
+	return
+}
+
+func (grpcServer) GetConfig(ctx context.Context, in *rpc.Key) (*rpc.Value, error) {
+	q := configQuery{Time: in.Time, At: in.At, Tag: in.Tag, Raw: in.Raw,
+		Client: getGRPCClient(ctx)}
+	v, version, level, namespace, err := getKeyConfig(in.Dc, in.Env, in.App,
+		in.Key, q)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rpc.Value{Value: v, Version: version, Level: level,
+		Namespace: namespace}, nil
+}
+
+func (grpcServer) GetAppConfig(ctx context.Context, in *rpc.Key) (*rpc.Config, error) {
+	q := configQuery{At: in.At, Tag: in.Tag, Raw: in.Raw,
+		Client: getGRPCClient(ctx)}
+	kvs, version, err := getWholeConfig(in.Dc, in.Env, in.App, q)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rpc.Config{Values: kvs, Version: version}, nil
+}
+
+func (grpcServer) CreateDcAndEnv(ctx context.Context, in *rpc.Key) (*rpc.Empty, error) {
+	if in.Dc == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing dc")
+	} else if in.Env == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing env")
+	}
+
+	err := backend.CreateDcAndEnv(in.Dc, in.Env)
+	printLog(err, "create dc=%s, env=%s", in.Dc, in.Env)
+	return &rpc.Empty{}, toStatus(err)
+}
+
+func (grpcServer) GetAllDcAndEnvs(ctx context.Context, in *rpc.Empty) (*rpc.DcAndEnvs, error) {
+	v, err := backend.GetAllDcAndEnvs()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	envs := make(map[string]*rpc.EnvList, len(v))
+	for dc, es := range v {
+		envs[dc] = &rpc.EnvList{Envs: es}
+	}
+	return &rpc.DcAndEnvs{Envs: envs}, nil
+}
+
+func (grpcServer) SetKeyValue(ctx context.Context, in *rpc.KeyValue) (*rpc.UploadResult, error) {
+	s, c, err := uploadConfig(in.Dc, in.Env, in.App, in.Key, in.Value,
+		in.Draft, in.At, getGRPCUser(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	result := &rpc.UploadResult{}
+	if s != nil {
+		if s.Value, err = maskValue(s.Dc, s.Env, s.App, s.Key, s.Value); err != nil {
+			return nil, toStatus(err)
+		}
+		result.Schedule = &rpc.Schedule{Id: s.ID, Dc: s.Dc, Env: s.Env,
+			App: s.App, Key: s.Key, Value: s.Value, At: s.At, Time: s.Time}
+	} else if c != nil {
+		var change store.Change
+		if result.Diff, result.Stale, err = getChangeDiff(*c); err != nil {
+			return nil, toStatus(err)
+		} else if change, result.Diff, err = maskChange(*c, result.Diff); err != nil {
+			return nil, toStatus(err)
+		}
+		c = &change
+		result.Change = &rpc.Change{Id: c.ID, Dc: c.Dc, Env: c.Env, App: c.App,
+			Key: c.Key, Value: c.Value, Base: c.Base, Requester: c.Requester,
+			Time: c.Time, Status: c.Status, Approvers: c.Approvers,
+			Reviewer: c.Reviewer, Reason: c.Reason, Version: c.Version,
+			Revision: c.Revision}
+	}
+	return result, nil
+}
+
+func (grpcServer) GetAllApps(ctx context.Context, in *rpc.ListRequest) (*rpc.NameList, error) {
+	page, size := getPage(in)
+	total, v, err := backend.GetAllApps(in.Dc, in.Env, in.Search, page, size)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rpc.NameList{Total: total, Names: v}, nil
+}
+
+func (grpcServer) GetAllKeys(ctx context.Context, in *rpc.ListRequest) (*rpc.NameList, error) {
+	page, size := getPage(in)
+	total, v, err := backend.GetAllKeys(in.Dc, in.Env, in.App, in.Search,
+		page, size)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rpc.NameList{Total: total, Names: v}, nil
+}
+
+func (grpcServer) GetAllValues(ctx context.Context, in *rpc.ListRequest) (*rpc.ValueList, error) {
+	page, size := getPage(in)
+	total, v, err := backend.GetAllValues(in.Dc, in.Env, in.App, in.Key,
+		page, size, in.From, in.To)
+	if err == nil {
+		err = maskValues(in.Dc, in.Env, in.App, in.Key, v)
+	}
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	versions := make([]int64, 0, len(v))
+	for version := range v {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	values := make([]*rpc.Value, len(versions))
+	for i, version := range versions {
+		values[i] = &rpc.Value{Value: v[version], Version: version}
+	}
+	return &rpc.ValueList{Total: total, Values: values}, nil
+}
+
+func (grpcServer) DeleteConfig(ctx context.Context, in *rpc.Key) (*rpc.Empty, error) {
+	_, err := removeConfig(in.Dc, in.Env, in.App, in.Key, in.Time, in.Purge)
+	return &rpc.Empty{}, toStatus(err)
+}
+
+func (grpcServer) GetCallback(ctx context.Context, in *rpc.Key) (*rpc.CallbackList, error) {
+	v, err := backend.GetCallback(in.Dc, in.Env, in.App, in.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &rpc.CallbackList{Callbacks: v}, nil
+}
+
+func (grpcServer) AddCallback(ctx context.Context, in *rpc.Callback) (*rpc.Empty, error) {
+	err := backend.AddCallback(in.Dc, in.Env, in.App, in.Key, in.Id,
+		in.Callback)
+	printLog(err, "Add the callback: dc=%s, env=%s, app=%s, key=%s, id=%s",
+		in.Dc, in.Env, in.App, in.Key, in.Id)
+	return &rpc.Empty{}, toStatus(err)
+}
+
+func (grpcServer) DeleteCallback(ctx context.Context, in *rpc.Callback) (*rpc.Empty, error) {
+	err := backend.DeleteCallback(in.Dc, in.Env, in.App, in.Key, in.Id)
+	printLog(err, "Delete the callback: dc=%s, env=%s, app=%s, key=%s, id=%s",
+		in.Dc, in.Env, in.App, in.Key, in.Id)
+	return &rpc.Empty{}, toStatus(err)
+}
+
+func (grpcServer) GetCallbackResult(ctx context.Context, in *rpc.Callback) (*rpc.CallbackResultList, error) {
+	v, err := backend.GetCallbackResult(in.Dc, in.Env, in.App, in.Key, in.Id)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	results := make([]*rpc.CallbackResult, len(v))
+	for i, r := range v {
+		t, _ := strconv.ParseInt(r[0], 10, 64)
+		results[i] = &rpc.CallbackResult{Time: t, Callback: r[1], Result: r[2]}
+	}
+	return &rpc.CallbackResultList{Results: results}, nil
+}
+
+func (grpcServer) Watch(in *rpc.WatchRequest, stream rpc.AppConfig_WatchServer) error {
+	last := int64(-1)
+	if in.LastEventId > 0 {
+		last = in.LastEventId
+	}
+
+	sub := eventWatcher.Subscribe(in.Dc, in.Env, in.App, in.Key)
+	defer eventWatcher.Unsubscribe(sub)
+
+	send := func(e store.Event) error {
+		return stream.Send(&rpc.Event{Id: e.ID, Dc: e.Dc, Env: e.Env, App: e.App,
+			Key: e.Key, Time: e.Time, Value: e.Value, Deleted: e.Deleted})
+	}
+	heartbeat := func() error { return nil }
+	err := streamEvents(sub, last, stream.Context().Done(), send, heartbeat)
+	if err == errSlowSubscriber {
+		// The client should watch again with the last event id.
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return err
+}
+
+// serveGRPC starts the gRPC server listening to addr, which is stopped
+// when the program exits.
+func serveGRPC(addr string) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		logger.Fatalf("failed to listen to %s for gRPC: %s", addr, err)
+	}
+
+	server := grpc.NewServer()
+	rpc.RegisterAppConfigServer(server, grpcServer{})
+	lifecycle.Register(server.Stop)
+
+	logger.Infof("Listening to %s for gRPC", addr)
+	if err = server.Serve(ln); err != nil {
+		logger.Errorf("the gRPC server stopped: %s", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/xgfone/appconfig/rpc"
+	"github.com/xgfone/appconfig/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCParity(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "prod")
+	backend.SetKeyValue("bj", "prod", "a", "k1", "v1")
+	backend.AddCanary(store.Canary{Dc: "bj", Env: "prod", App: "a", Key: "k1",
+		Value: "v2", Instances: []string{"i1"}})
+
+	var s grpcServer
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-appconfig-instance", "i1", "x-appconfig-user", "alice"))
+	key := &rpc.Key{Dc: "bj", Env: "prod", App: "a", Key: "k1"}
+	if v, err := s.GetConfig(ctx, key); err != nil {
+		t.Fatal(err)
+	} else if v.Value != "v2" {
+		t.Errorf("expected the candidate value 'v2', but got '%s'", v.Value)
+	}
+	if v, err := s.GetConfig(context.Background(), key); err != nil {
+		t.Fatal(err)
+	} else if v.Value != "v1" {
+		t.Errorf("expected the stable value 'v1', but got '%s'", v.Value)
+	}
+
+	setProtectedEnvs("prod", 1)
+	defer setProtectedEnvs("", 0)
+
+	kv := &rpc.KeyValue{Dc: "bj", Env: "prod", App: "a", Key: "k1", Value: "v3"}
+	if _, err := s.SetKeyValue(context.Background(), kv); status.Code(err) !=
+		codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without the user, but got %v", err)
+	}
+	if r, err := s.SetKeyValue(ctx, kv); err != nil {
+		t.Fatal(err)
+	} else if r.Change == nil || r.Change.Requester != "alice" ||
+		r.Change.Status != store.ChangePending {
+		t.Errorf("expected the pending change by alice, but got %v", r.Change)
+	}
+	if v, _, _ := backend.AppGetConfig("bj", "prod", "a", "k1", 0); v != "v1" {
+		t.Errorf("expected the value unchanged, but got '%s'", v)
+	}
+}
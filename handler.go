@@ -1,9 +1,14 @@
 package main
 
 import (
+	"crypto/sha1"
 	"fmt"
+	"hash/crc32"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/xgfone/appconfig/callback"
@@ -12,6 +17,12 @@ import (
 	"github.com/xgfone/go-tools/net2/http2"
 )
 
+// versionHeader is the response header of the version of the value.
+const versionHeader = "X-Appconfig-Version"
+
+// maxWaitTime is the maximum time to wait for the change of the config.
+const maxWaitTime = 5 * time.Minute
+
 var (
 	backend   store.Store
 	inCbChan  chan map[string][2]string
@@ -57,6 +68,77 @@ func handleCbResult(out <-chan map[string][2]string) {
 
 }
 
+// notifyCallbacks notifies the apps watching the key asynchronously
+// that the value has been changed.
+//
+// The callbacks of the keys referencing the key, of the key in the apps
+// depending on it as a namespace, and of the key in the envs inheriting it,
+// are notified, too.
+func notifyCallbacks(dc, env, app, key, value string) error {
+	if err := notifyKey(dc, env, app, key, value); err != nil {
+		return err
+	}
+	return notifyInheritors(dc, env, app, key, value)
+}
+
+// notifyKey notifies the callbacks of the key, of the keys referencing it,
+// and of the key in the apps depending on it as a namespace.
+func notifyKey(dc, env, app, key, value string) error {
+	if err := notifyCallbacksIf(dc, env, app, key, value, nil); err != nil {
+		return err
+	}
+	if err := notifyDependents(dc, env, app, key); err != nil {
+		return err
+	}
+	return notifyConsumers(dc, env, app, key, value)
+}
+
+// notifyCallbacksIf notifies the callbacks of the key, for which filter
+// returns true, with the resolved value. If filter is nil, notify all.
+//
+// If failed to resolve the value, the callbacks are not notified.
+func notifyCallbacksIf(dc, env, app, key, value string,
+	filter func(id, cb string) bool) error {
+	cs, err := backend.GetCallback(dc, env, app, key)
+	if err == store.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	} else if len(cs) == 0 {
+		return nil
+	}
+
+	info := make(map[string][2]string, len(cs))
+	for id, cb := range cs {
+		if filter == nil || filter(id, cb) {
+			info[getCbKey(dc, env, app, key, id)] = [2]string{cb}
+		}
+	}
+	if len(info) == 0 {
+		return nil
+	}
+
+	if value, err = newResolver(dc, env).Resolve(app, key, value); err != nil {
+		logger.Errorf("cannot notify the callbacks of dc=%s, env=%s, app=%s, key=%s: %s",
+			dc, env, app, key, err)
+		return nil
+	}
+	for k, v := range info {
+		info[k] = [2]string{v[0], value}
+	}
+	inCbChan <- info
+	return nil
+}
+
+// setKeyValue sets the value of the key into the backend store, then notifies
+// the apps watching the key that the value has been changed.
+func setKeyValue(dc, env, app, key, value string) error {
+	if err := backend.SetKeyValue(dc, env, app, key, value); err != nil {
+		return err
+	}
+	return notifyCallbacks(dc, env, app, key, value)
+}
+
 // InitStore the backend store.
 func InitStore(storeName, conf string) error {
 	if backend = store.GetStore(storeName); backend == nil {
@@ -71,40 +153,117 @@ func init() {
 	go callback.Notify(inCbChan, outCbChan)
 	go handleCbResult(outCbChan)
 
-	wrap := http2.ErrorHandler
-
 	r := mux.NewRouter()
 	v1 := r.PathPrefix("/v1").Subrouter().StrictSlash(true)
+	registerRoutes(v1, http2.ErrorHandler)
+	v1.Handle("/openapi.json", http2.ErrorHandler(GetOpenAPI)).Methods("GET")
+
+	v2 := r.PathPrefix("/v2").Subrouter().StrictSlash(true)
+	registerV2Routes(v2)
+
+	handler = r
+}
+
+// handlerWrapper wraps the handler returning an error as http.Handler.
+type handlerWrapper func(func(http.ResponseWriter, *http.Request) error) http.Handler
 
+// registerRoutes registers the routes of the API into the router v,
+// the handlers of which are wrapped by wrap.
+func registerRoutes(v *mux.Router, wrap handlerWrapper) {
 	// App Config
-	v1.Handle("/app/{dc}/{env}/{app}/{key}", wrap(AppGetConfig)).Methods("GET")
+	v.Handle("/app/{dc}/{env}/{app}", wrap(AppGetAllConfig)).Methods("GET")
+	v.Handle("/app/{dc}/{env}/{app}/{key}", wrap(AppGetConfig)).Methods("GET")
+
+	// Change Event Stream
+	v.Handle("/stream/{dc}/{env}", wrap(StreamEvents)).Methods("GET")
+	v.Handle("/stream/{dc}/{env}/{app}", wrap(StreamEvents)).Methods("GET")
 
 	// Admin Config
-	v1.Handle("/admin", wrap(CreateDcAndEnv)).Methods("POST")
-	v1.Handle("/admin", wrap(GetAllDcAndEnvs)).Methods("GET")
+	v.Handle("/admin", wrap(CreateDcAndEnv)).Methods("POST")
+	v.Handle("/admin", wrap(GetAllDcAndEnvs)).Methods("GET").
+		Name("GetAllDcAndEnvs")
 
-	admin := v1.PathPrefix("/admin").Subrouter()
+	admin := v.PathPrefix("/admin").Subrouter()
+	admin.Handle("/promote", wrap(PromoteConfig)).Methods("POST")
+	admin.Handle("/clone", wrap(CloneConfig)).Methods("POST")
+	admin.Handle("/search", wrap(SearchConfig)).Methods("GET")
+	admin.Handle("/{dc}/{env}/{app}", wrap(ImportConfig)).Methods("POST")
+	admin.Handle("/{dc}/{env}/{app}/rollback", wrap(RollbackApp)).
+		Methods("POST").Queries("time", "{time}")
+	admin.Handle("/{dc}/{env}/{app}/move", wrap(MoveApp)).
+		Methods("POST").Queries("to", "{to}")
+	admin.Handle("/{dc}/{env}/{app}/publish", wrap(PublishDrafts)).Methods("POST")
 	admin.Handle("/{dc}/{env}/{app}/{key}", wrap(UploadConfig)).Methods("POST")
+	admin.Handle("/{dc}/{env}/{app}/{key}/rollback", wrap(RollbackKey)).Methods("POST")
+	admin.Handle("/{dc}/{env}/{app}/{key}/move", wrap(MoveKey)).Methods("POST")
+	admin.Handle("/{dc}/{env}/{app}/{key}/rollback", wrap(GetRollbacks)).Methods("GET")
+	admin.Handle("/{dc}/{env}/{app}/{key}/metadata", wrap(GetMetadata)).Methods("GET")
+	admin.Handle("/{dc}/{env}/{app}/{key}/metadata", wrap(SetMetadata)).Methods("POST")
+	admin.Handle("/{dc}/{env}/{app}/{key}/metadata", wrap(DeleteMetadata)).Methods("DELETE")
 
 	admin.Handle("/{dc}/{env}", wrap(GetAllApps)).Methods("GET")
 	admin.Handle("/{dc}/{env}/{app}", wrap(GetAllKeys)).Methods("GET")
-	admin.Handle("/{dc}/{env}/{app}/{key}", wrap(GetAllValues)).Methods("GET")
+	admin.Handle("/{dc}/{env}/{app}/diff", wrap(DiffApp)).
+		Methods("GET").Queries("against", "{against}")
+	admin.Handle("/{dc}/{env}/{app}/{key}", wrap(GetAllValues)).Methods("GET").
+		Name("GetAllValues")
+	admin.Handle("/{dc}/{env}/{app}/{key}/diff", wrap(DiffKey)).Methods("GET")
 
 	admin.Handle("/{dc}", wrap(DeleteDc)).Methods("DELETE")
 	admin.Handle("/{dc}/{env}", wrap(DeleteEnv)).Methods("DELETE")
 	admin.Handle("/{dc}/{env}/{app}", wrap(DeleteApp)).Methods("DELETE")
 	admin.Handle("/{dc}/{env}/{app}/{key}", wrap(DeleteKey)).Methods("DELETE")
 
+	// Change
+	v.Handle("/change", wrap(GetChanges)).Methods("GET")
+	v.Handle("/change/{id}", wrap(GetChange)).Methods("GET")
+	v.Handle("/change/{id}/approve", wrap(ApproveChange)).Methods("POST")
+	v.Handle("/change/{id}/reject", wrap(RejectChange)).Methods("POST")
+
+	// Namespace Dependency
+	v.Handle("/dependency/{dc}/{env}/{app}", wrap(GetDependencies)).Methods("GET")
+	v.Handle("/dependency/{dc}/{env}/{app}", wrap(SetDependencies)).Methods("POST")
+	v.Handle("/dependency/{dc}/{env}/{app}", wrap(DeleteDependencies)).Methods("DELETE")
+	v.Handle("/dependency/{dc}/{env}/{namespace}/dependents", wrap(GetDependents)).Methods("GET")
+
+	// Canary
+	v.Handle("/canary/{dc}/{env}/{app}", wrap(GetCanaries)).Methods("GET")
+	v.Handle("/canary/{dc}/{env}/{app}/{key}", wrap(CreateCanary)).Methods("POST")
+	v.Handle("/canary/{dc}/{env}/{app}/{key}", wrap(GetCanary)).Methods("GET")
+	v.Handle("/canary/{dc}/{env}/{app}/{key}", wrap(AbortCanary)).Methods("DELETE")
+	v.Handle("/canary/{dc}/{env}/{app}/{key}/widen", wrap(WidenCanary)).Methods("POST")
+	v.Handle("/canary/{dc}/{env}/{app}/{key}/promote", wrap(PromoteCanary)).Methods("POST")
+
+	// Schedule
+	v.Handle("/schedule", wrap(GetSchedules)).Methods("GET")
+	v.Handle("/schedule/{id}", wrap(CancelSchedule)).Methods("DELETE")
+
+	// Draft
+	v.Handle("/draft/{dc}/{env}/{app}", wrap(GetDrafts)).Methods("GET")
+	v.Handle("/draft/{dc}/{env}/{app}", wrap(DiscardDrafts)).Methods("DELETE")
+	v.Handle("/draft/{dc}/{env}/{app}/{key}", wrap(DiscardDrafts)).Methods("DELETE")
+
+	// Tag
+	v.Handle("/tag/{dc}/{env}/{app}", wrap(GetTags)).Methods("GET")
+	v.Handle("/tag/{dc}/{env}/{app}/{name}", wrap(CreateTag)).Methods("POST")
+	v.Handle("/tag/{dc}/{env}/{app}/{name}", wrap(GetTag)).Methods("GET")
+	v.Handle("/tag/{dc}/{env}/{app}/{name}", wrap(DeleteTag)).Methods("DELETE")
+
+	// Trash
+	v.Handle("/trash", wrap(GetTrashes)).Methods("GET")
+	v.Handle("/trash/{id}", wrap(RestoreTrash)).Methods("POST")
+	v.Handle("/trash/{id}", wrap(PurgeTrash)).Methods("DELETE")
+
 	// Callback Notification
-	cb := v1.PathPrefix("/callback").Subrouter()
-	cb.Handle("/{dc}/{env}/{app}/{key}", wrap(GetCallback)).Methods("GET")
+	cb := v.PathPrefix("/callback").Subrouter()
+	cb.Handle("/{dc}/{env}/{app}/{key}", wrap(GetCallback)).Methods("GET").
+		Name("GetCallback")
 	cb.Handle("/{dc}/{env}/{app}/{key}/{id}", wrap(AddCallback)).Methods("POST")
 	cb.Handle("/{dc}/{env}/{app}/{key}", wrap(DeleteCallback)).Methods("DELETE")
 
 	// Callback Notification Result
-	cb.Handle("/{dc}/{env}/{app}/{key}/{id}", wrap(GetCallbackResult)).Methods("GET")
-
-	handler = r
+	cb.Handle("/{dc}/{env}/{app}/{key}/{id}", wrap(GetCallbackResult)).Methods("GET").
+		Name("GetCallbackResult")
 }
 
 func renderError(w http.ResponseWriter, err error) error {
@@ -116,18 +275,350 @@ func renderError(w http.ResponseWriter, err error) error {
 		w.WriteHeader(http.StatusNotFound)
 	case store.ErrNoDcAndEnv:
 		return http2.String(w, http.StatusBadRequest, "no dc and env")
+	case store.ErrConflict:
+		w.WriteHeader(http.StatusConflict)
 	default:
-		logger.Errorf("Get an error: %s", err)
 		if e, ok := err.(http2.HTTPError); ok {
 			return http2.Error(w, e, e.Code)
 		}
+		logger.Errorf("Get an error: %s", err)
 		return http2.Error(w, err)
 	}
 	return nil
 }
 
+// badRequestError returns the error of the invalid arguments, which is
+// rendered as 400.
+func badRequestError(format string, args ...interface{}) error {
+	return http2.NewHTTPError(http.StatusBadRequest, fmt.Errorf(format, args...))
+}
+
+// getQueryBool returns the bool value of the query argument key.
+//
+// Return false if the argument does not exist.
+func getQueryBool(query url.Values, key string) (bool, error) {
+	v := http2.GetQuery(query, key)
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// checkNotModified sets the response headers ETag and Last-Modified,
+// then reports whether the resource has not been modified according to
+// the request headers If-None-Match and If-Modified-Since.
+//
+// If modtime is 0 or negative, Last-Modified and If-Modified-Since are ignored.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string,
+	modtime int64) bool {
+
+	setCacheHeaders(w, etag, modtime)
+
+	// If-Modified-Since is ignored when If-None-Match is present.
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, v := range strings.Split(inm, ",") {
+			v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+			if v == "*" || v == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && modtime > 0 {
+		if t, err := http.ParseTime(ims); err == nil && modtime <= t.Unix() {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheHeaders sets the response headers ETag and Last-Modified.
+//
+// If modtime is 0 or negative, Last-Modified is ignored.
+func setCacheHeaders(w http.ResponseWriter, etag string, modtime int64) {
+	w.Header().Set("ETag", etag)
+	if modtime > 0 {
+		w.Header().Set("Last-Modified",
+			time.Unix(modtime, 0).UTC().Format(http.TimeFormat))
+	}
+}
+
+// getETag returns the strong ETag of the value of a key at the version.
+func getETag(version int64, value string) string {
+	return fmt.Sprintf(`"%x-%08x"`, version, crc32.ChecksumIEEE([]byte(value)))
+}
+
+// getQueryDuration returns the duration of the query argument key, which is
+// either the seconds, such as "60", or the duration string, such as "60s".
+//
+// Return 0 if the argument does not exist.
+func getQueryDuration(query url.Values, key string) (time.Duration, error) {
+	v := http2.GetQuery(query, key)
+	if v == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.ParseDuration(v)
+}
+
+// getAllApps returns the names of all the apps in dc and env.
+func getAllApps(dc, env string) ([]string, error) {
+	const size = 100
+	apps := make([]string, 0, size)
+	for page := int64(1); ; page++ {
+		_, as, err := backend.GetAllApps(dc, env, "", page, size)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, as...)
+		if int64(len(as)) < size {
+			return apps, nil
+		}
+	}
+}
+
+// getAllKeys returns the names of all the keys of the app in dc and env.
+func getAllKeys(dc, env, app string) ([]string, error) {
+	const size = 100
+	keys := make([]string, 0, size)
+	for page := int64(1); ; page++ {
+		_, ks, err := backend.GetAllKeys(dc, env, app, "", page, size)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, ks...)
+		if int64(len(ks)) < size {
+			return keys, nil
+		}
+	}
+}
+
+// getAppConfig returns the latest values of all the keys of the app
+// in dc and env, and the latest version of them.
+func getAppConfig(dc, env, app string) (map[string]string, int64, error) {
+	keys, err := getAllKeys(dc, env, app)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var version int64
+	kvs := make(map[string]string, len(keys))
+	for _, key := range keys {
+		v, t, err := backend.AppGetConfig(dc, env, app, key, 0)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		kvs[key] = v
+		if t > version {
+			version = t
+		}
+	}
+
+	if len(kvs) == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+	return kvs, version, nil
+}
+
+// getAppConfigAsOf returns the values of all the keys of the app in dc
+// and env at the unixstamp time at, and the newest version of them,
+// like getAppConfig.
+func getAppConfigAsOf(dc, env, app string, at int64) (map[string]string,
+	int64, error) {
+	vs, err := backend.GetConfigAsOf(dc, env, app, "", at)
+	if err != nil {
+		return nil, 0, err
+	} else if len(vs) == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+
+	var version int64
+	kvs := make(map[string]string, len(vs))
+	for key, v := range vs {
+		kvs[key] = v.Value
+		if v.Time > version {
+			version = v.Time
+		}
+	}
+	return kvs, version, nil
+}
+
+// configQuery is the query of the config by the app for both the REST API
+// and the gRPC service.
+//
+// If Time is greater than 0, return the value of that version. Or if At is
+// greater than 0, return the newest version at or before that time. Or if Tag
+// is not "", return the version pinned by the tag. Or if Client matches
+// the canary of the key, return the candidate value instead of the latest
+// value. The references in the values are resolved unless Raw is true.
+type configQuery struct {
+	Time   int64
+	At     int64
+	Tag    string
+	Raw    bool
+	Client configClient
+}
+
+// check returns an error if the arguments of the query conflict.
+func (q configQuery) check() error {
+	if q.At > 0 && q.Time > 0 {
+		return badRequestError("at cannot be used with time")
+	} else if q.Tag != "" && (q.At > 0 || q.Time > 0) {
+		return badRequestError("tag cannot be used with time or at")
+	}
+	return nil
+}
+
+// getKeyConfig returns the value of the key by the query, its version,
+// and the level and the namespace serving it.
+func getKeyConfig(dc, env, app, key string, q configQuery) (v string,
+	version int64, level, namespace string, err error) {
+	if err = q.check(); err != nil {
+		return
+	}
+
+	t := q.Time
+	if q.Tag != "" {
+		if t, err = getTaggedVersion(dc, env, app, key, q.Tag); err != nil {
+			return
+		}
+	}
+
+	level = levelEnv
+	if q.At > 0 {
+		var versions map[string]store.Version
+		versions, err = backend.GetConfigAsOf(dc, env, app, key, q.At)
+		if err == nil {
+			if _v, ok := versions[key]; ok {
+				v, version = _v.Value, _v.Time
+			} else {
+				err = store.ErrNotFound
+			}
+		}
+	} else if t > 0 {
+		v, version, err = backend.AppGetConfig(dc, env, app, key, t)
+	} else {
+		v, version, level, namespace, err = getAppValue(dc, env, app, key)
+		if err == nil {
+			// Serve the candidate value to the client matching the canary,
+			// only if the key exists.
+			c, e := getMatchedCanary(q.Client, dc, env, app, key)
+			if e != nil {
+				err = e
+			} else if c != nil {
+				v, version, level, namespace, err = c.Value, c.Time, levelEnv, "", nil
+			}
+		}
+	}
+	if err == nil && !q.Raw {
+		v, err = newResolver(dc, env).Resolve(app, key, v)
+	}
+	return
+}
+
+// getWholeConfig returns the values of all the keys of the app by the query,
+// and the newest version of them. Time of the query is ignored.
+func getWholeConfig(dc, env, app string, q configQuery) (kvs map[string]string,
+	version int64, err error) {
+	if q.At > 0 && q.Tag != "" {
+		return nil, 0, badRequestError("at cannot be used with tag")
+	}
+
+	if q.At > 0 {
+		kvs, version, err = getAppConfigAsOf(dc, env, app, q.At)
+	} else if q.Tag != "" {
+		kvs, version, err = getAppConfigByTag(dc, env, app, q.Tag)
+	} else {
+		kvs, version, err = getEffectiveConfig(dc, env, app)
+		if err == nil {
+			version, err = overlayCanaries(q.Client, dc, env, app, kvs, version)
+		}
+	}
+	if err == nil && !q.Raw {
+		err = resolveValues(dc, env, app, kvs)
+	}
+	return
+}
+
+// AppGetAllConfig returns the latest values of all the keys of the app
+// as a whole document, the format of which is JSON, YAML, TOML, dotenv
+// or Java properties.
+//
+// If the query argument at is given, return the values at that time instead.
+// If the query argument tag is given, return the values pinned by the tag.
+// Or the latest values are replaced with the candidate values of the canaries
+// matching the client.
+//
+// The references in the values are resolved unless the query argument raw
+// is true.
+//
+// This interface is only accessed by the app.
+func AppGetAllConfig(w http.ResponseWriter, r *http.Request) error {
+	format, err := getFormat(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusNotAcceptable)
+	}
+
+	query := r.URL.Query()
+	nested, err := getQueryBool(query, "nested")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	raw, err := getQueryBool(query, "raw")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	at, err := http2.GetQueryInt64(query, "at")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	vs := mux.Vars(r)
+	q := configQuery{At: at, Tag: http2.GetQuery(query, "tag"), Raw: raw,
+		Client: getRequestClient(r)}
+	kvs, version, err := getWholeConfig(vs["dc"], vs["env"], vs["app"], q)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	data, err := renderConfig(format, kvs, nested)
+	if err != nil {
+		return http2.Error(w, err, http.StatusConflict)
+	}
+
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(data))
+	if checkNotModified(w, r, etag, version) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.WriteHeader(http.StatusOK)
+	_, err = w.Write(data)
+	return err
+}
+
 // AppGetConfig returns the app config information.
 //
+// If the query argument wait is given, it blocks until a newer version than
+// the query argument since is set, or until the wait time passes.
+//
+// If the query argument at is given, return the newest version at or before
+// that time. If the query argument tag is given, return the version pinned
+// by the tag. Or if the client matches the canary of the key, return
+// the candidate value instead of the latest value.
+//
+// The references in the value are resolved unless the query argument raw
+// is true.
+//
 // This interface is only accessed by the app.
 func AppGetConfig(w http.ResponseWriter, r *http.Request) error {
 	query := r.URL.Query()
@@ -136,13 +627,108 @@ func AppGetConfig(w http.ResponseWriter, r *http.Request) error {
 		return http2.Error(w, err, http.StatusBadRequest)
 	}
 
+	at, err := http2.GetQueryInt64(query, "at")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	wait, err := getQueryDuration(query, "wait")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	since, err := http2.GetQueryInt64(query, "since")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	raw, err := getQueryBool(query, "raw")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	tag := http2.GetQuery(query, "tag")
+	if wait > 0 && (at > 0 || tag != "") {
+		return http2.String(w, http.StatusBadRequest,
+			"wait cannot be used with at or tag")
+	}
+
 	vs := mux.Vars(r)
+	q := configQuery{Time: t, At: at, Tag: tag, Raw: raw,
+		Client: getRequestClient(r)}
+	if err = q.check(); err != nil {
+		return renderError(w, err)
+	} else if wait > 0 && t < 1 {
+		return watchConfig(w, r, vs["dc"], vs["env"], vs["app"], vs["key"],
+			since, wait, raw)
+	}
 
-	v, err := backend.AppGetConfig(vs["dc"], vs["env"], vs["app"], vs["key"], t)
-	if err == nil {
-		return http2.String(w, http.StatusOK, "%s", v)
+	v, version, level, namespace, err := getKeyConfig(vs["dc"], vs["env"],
+		vs["app"], vs["key"], q)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	w.Header().Set(versionHeader, strconv.FormatInt(version, 10))
+	w.Header().Set(levelHeader, level)
+	if namespace != "" {
+		w.Header().Set(namespaceHeader, namespace)
+	}
+	if checkNotModified(w, r, getETag(version, v), version) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	return http2.String(w, http.StatusOK, "%s", v)
+}
+
+// watchConfig blocks until a newer version of the key than since is set,
+// then returns it. If the wait time passes, it returns 304. If the key has
+// been deleted, it returns 404. The references in the value are resolved
+// unless raw is true.
+func watchConfig(w http.ResponseWriter, r *http.Request, dc, env, app,
+	key string, since int64, wait time.Duration, raw bool) error {
+
+	if wait > maxWaitTime {
+		wait = maxWaitTime
+	}
+
+	// Subscribe the change events before getting the value
+	// in order not to miss them.
+	sub := eventWatcher.Subscribe(dc, env, app, key)
+	defer func() { eventWatcher.Unsubscribe(sub) }()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	var deleted bool
+	for {
+		v, version, err := backend.AppGetConfig(dc, env, app, key, 0)
+		if err == nil && version > since {
+			if !raw {
+				if v, err = newResolver(dc, env).Resolve(app, key, v); err != nil {
+					return renderError(w, err)
+				}
+			}
+			w.Header().Set(versionHeader, strconv.FormatInt(version, 10))
+			setCacheHeaders(w, getETag(version, v), version)
+			return http2.String(w, http.StatusOK, "%s", v)
+		} else if err != nil && (err != store.ErrNotFound || deleted) {
+			return renderError(w, err)
+		}
+
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				sub = eventWatcher.Subscribe(dc, env, app, key)
+			}
+			deleted = event.Deleted
+		case <-timer.C:
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		case <-r.Context().Done():
+			return nil
+		}
 	}
-	return renderError(w, err)
 }
 
 // CreateDcAndEnv create the new dc and env.
@@ -173,38 +759,159 @@ func GetAllDcAndEnvs(w http.ResponseWriter, r *http.Request) error {
 }
 
 // UploadConfig uploads the app config information.
+//
+// If the query argument draft is true, save it as a draft, which is not
+// visible to the app until published. Or if the env is protected, propose
+// a change, which is set only when approved.
+//
+// If giving the query argument at, schedule the value to be set at that time.
 func UploadConfig(w http.ResponseWriter, r *http.Request) error {
 	v, err := http2.GetBody(r)
 	if err != nil {
 		return http2.Error(w, err, http.StatusBadRequest)
 	}
 
+	query := r.URL.Query()
+	draft, err := getQueryBool(query, "draft")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	at, err := http2.GetQueryInt64(query, "at")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	vs := mux.Vars(r)
+	s, c, err := uploadConfig(vs["dc"], vs["env"], vs["app"], vs["key"],
+		string(v), draft, at, getRequestUser(r))
+	if err != nil {
+		return renderError(w, err)
+	} else if s != nil {
+		if s.Value, err = maskValue(s.Dc, s.Env, s.App, s.Key, s.Value); err != nil {
+			return renderError(w, err)
+		}
+		return http2.JSON(w, http.StatusAccepted,
+			map[string]interface{}{"schedule": s})
+	} else if c != nil {
+		return renderChange(w, http.StatusAccepted, *c)
+	}
+	return nil
+}
+
+// uploadConfig uploads the value of the key for both the REST API and
+// the gRPC service.
+//
+// If draft is true, save it as a draft. Or if at is greater than 0, schedule
+// it to be set at that time. Or if the env is protected, propose a change
+// by the requester. Or set it and notify the callbacks.
+func uploadConfig(dc, env, app, key, value string, draft bool, at int64,
+	requester string) (*store.Schedule, *store.Change, error) {
+	if err := checkKeyName(key); err != nil {
+		return nil, nil, err
+	} else if at > 0 && draft {
+		return nil, nil, badRequestError("at cannot be used with draft")
+	} else if draft {
+		err := backend.SetDraft(dc, env, app, key, value)
+		printLog(err, "Upload the draft, dc=%s, env=%s, app=%s, key=%s",
+			dc, env, app, key)
+		return nil, nil, err
+	} else if at > 0 {
+		s, err := scheduleConfig(dc, env, app, key, value, at)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &s, nil, nil
+	} else if isProtected(dc, env) {
+		c, err := proposeChange(dc, env, app, key, value, requester)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &c, nil
+	}
+
+	err := setKeyValue(dc, env, app, key, value)
+	printLog(err, "Upload the app config, dc=%s, env=%s, app=%s, key=%s",
+		dc, env, app, key)
+	return nil, nil, err
+}
+
+// ImportConfig parses the uploaded configuration file and sets the values
+// of all the changed keys of the app.
+func ImportConfig(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	format, err := getImportFormat(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusUnsupportedMediaType)
+	}
+
+	dryRun, err := getQueryBool(query, "dry_run")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	sep := http2.GetQuery(query, "sep")
+	if sep == "" {
+		sep = "."
+	}
+
+	body, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	v, err := parseConfig(format, body)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+	kvs, err := flattenConfig(v, sep)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	} else if _, ok := kvs[reservedKey]; ok {
+		return renderError(w, checkKeyName(reservedKey))
+	}
+
 	vs := mux.Vars(r)
 	dc := vs["dc"]
 	env := vs["env"]
 	app := vs["app"]
-	key := vs["key"]
-	value := string(v)
+	if !dryRun && isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
 
-	err = backend.SetKeyValue(dc, env, app, key, value)
-	printLog(err, "Upload the app config, dc=%s, env=%s, app=%s, key=%s",
-		dc, env, app, key)
+	added := make([]string, 0, len(kvs))
+	changed := make([]string, 0, len(kvs))
+	unchanged := make([]string, 0, len(kvs))
+	for _, key := range sortedKeys(kvs) {
+		old, _, err := backend.AppGetConfig(dc, env, app, key, 0)
+		switch {
+		case err == store.ErrNotFound:
+			added = append(added, key)
+		case err != nil:
+			return renderError(w, err)
+		case old == kvs[key]:
+			unchanged = append(unchanged, key)
+			continue
+		default:
+			changed = append(changed, key)
+		}
 
-	// Notify the apps that the value has been changed.
-	if err == nil {
-		var cs map[string]string
-		cs, err = backend.GetCallback(dc, env, app, key)
-		if err == nil && len(cs) > 0 {
-			info := make(map[string][2]string)
-			for id, cb := range cs {
-				key := getCbKey(dc, env, app, key, id)
-				info[key] = [2]string{cb, value}
+		if !dryRun {
+			err = setKeyValue(dc, env, app, key, kvs[key])
+			printLog(err, "Import the app config, dc=%s, env=%s, app=%s, key=%s",
+				dc, env, app, key)
+			if err != nil {
+				return renderError(w, err)
 			}
-			inCbChan <- info
 		}
 	}
 
-	return renderError(w, err)
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run":   dryRun,
+		"added":     added,
+		"changed":   changed,
+		"unchanged": unchanged,
+	})
 }
 
 // GetAllApps returns all apps in dc and env.
@@ -238,6 +945,9 @@ func GetAllApps(w http.ResponseWriter, r *http.Request) error {
 }
 
 // GetAllKeys returns all keys in dc, env and app.
+//
+// If the query argument inherited is true, the keys inherited by the fallback
+// chain are returned, too, with the values at each level.
 func GetAllKeys(w http.ResponseWriter, r *http.Request) error {
 	query := r.URL.Query()
 
@@ -257,7 +967,24 @@ func GetAllKeys(w http.ResponseWriter, r *http.Request) error {
 		size = 20
 	}
 
+	inherited, err := getQueryBool(query, "inherited")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
 	search := http2.GetQuery(query, "search")
+	label := http2.GetQuery(query, "label")
+	owner := http2.GetQuery(query, "owner")
+	if inherited {
+		if label != "" || owner != "" {
+			return http2.String(w, http.StatusBadRequest,
+				"label and owner cannot be used with inherited")
+		}
+		return getInheritedKeys(w, r, search, page, size)
+	} else if label != "" || owner != "" {
+		return getKeysByMetadata(w, r, search, label, owner, page, size)
+	}
+
 	vs := mux.Vars(r)
 	total, v, err := backend.GetAllKeys(vs["dc"], vs["env"], vs["app"], search,
 		page, size)
@@ -268,6 +995,35 @@ func GetAllKeys(w http.ResponseWriter, r *http.Request) error {
 		map[string]interface{}{"total": total, "keys": v})
 }
 
+// getKeysByMetadata renders the keys of the app like GetAllKeys, but only
+// the keys, the metadata of which has the label and the owner if not "".
+func getKeysByMetadata(w http.ResponseWriter, r *http.Request, search, label,
+	owner string, page, size int64) error {
+	vs := mux.Vars(r)
+	mds, err := backend.GetAllMetadata(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	keys, err := getAllKeys(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	matched := make([]string, 0, len(keys))
+	for _, key := range keys {
+		md, ok := mds[key]
+		if !ok || (search != "" && !strings.Contains(key, search)) ||
+			(label != "" && !hasLabel(md, label)) ||
+			(owner != "" && md.Owner != owner) {
+			continue
+		}
+		matched = append(matched, key)
+	}
+
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"total": len(matched), "keys": store.GetStringPage(matched, page, size)})
+}
+
 // GetAllValues returns all values of the key in dc, env and app.
 func GetAllValues(w http.ResponseWriter, r *http.Request) error {
 	query := r.URL.Query()
@@ -301,6 +1057,9 @@ func GetAllValues(w http.ResponseWriter, r *http.Request) error {
 	vs := mux.Vars(r)
 	total, v, err := backend.GetAllValues(vs["dc"], vs["env"], vs["app"],
 		vs["key"], page, size, from, to)
+	if err == nil {
+		err = maskValues(vs["dc"], vs["env"], vs["app"], vs["key"], v)
+	}
 	if err != nil {
 		return renderError(w, err)
 	}
@@ -308,31 +1067,30 @@ func GetAllValues(w http.ResponseWriter, r *http.Request) error {
 		map[string]interface{}{"total": total, "values": v})
 }
 
-// DeleteDc deletes the whole dc.
+// DeleteDc moves the whole dc into the trash, or deletes it permanently
+// if the query argument purge is true.
 func DeleteDc(w http.ResponseWriter, r *http.Request) (err error) {
 	vs := mux.Vars(r)
-	err = backend.DeleteConfig(vs["dc"], "", "", "", 0)
-	printLog(err, "Delete dc=%s", vs["dc"])
-	return renderError(w, err)
+	return renderRemove(w, r, vs["dc"], "", "", "", 0)
 }
 
-// DeleteEnv deletes the whole env.
+// DeleteEnv moves the whole env into the trash, or deletes it permanently
+// if the query argument purge is true.
 func DeleteEnv(w http.ResponseWriter, r *http.Request) (err error) {
 	vs := mux.Vars(r)
-	err = backend.DeleteConfig(vs["dc"], vs["env"], "", "", 0)
-	printLog(err, "Delete dc=%s, env=%s", vs["dc"], vs["env"])
-	return renderError(w, err)
+	return renderRemove(w, r, vs["dc"], vs["env"], "", "", 0)
 }
 
-// DeleteApp deletes the whole app.
+// DeleteApp moves the whole app into the trash, or deletes it permanently
+// if the query argument purge is true.
 func DeleteApp(w http.ResponseWriter, r *http.Request) (err error) {
 	vs := mux.Vars(r)
-	err = backend.DeleteConfig(vs["dc"], vs["env"], vs["app"], "", 0)
-	printLog(err, "Delete dc=%s, env=%s, app=%s", vs["dc"], vs["env"], vs["app"])
-	return renderError(w, err)
+	return renderRemove(w, r, vs["dc"], vs["env"], vs["app"], "", 0)
 }
 
-// DeleteKey deletes the whole key.
+// DeleteKey moves the whole key into the trash, or deletes it permanently
+// if the query argument purge is true. A version of the key given by
+// the query argument time is always deleted permanently.
 func DeleteKey(w http.ResponseWriter, r *http.Request) (err error) {
 	query := r.URL.Query()
 	t, err := http2.GetQueryInt64(query, "time")
@@ -341,15 +1099,21 @@ func DeleteKey(w http.ResponseWriter, r *http.Request) (err error) {
 	}
 
 	vs := mux.Vars(r)
-	err = backend.DeleteConfig(vs["dc"], vs["env"], vs["app"], vs["key"], t)
-	printLog(err, "Delete dc=%s, env=%s, app=%s, key=%s, time=%d", vs["dc"],
-		vs["env"], vs["app"], vs["key"], t)
+	return renderRemove(w, r, vs["dc"], vs["env"], vs["app"], vs["key"], t)
+}
+
+// deleteConfig deletes the config like DeleteConfig of the backend store.
+// When deleting the whole key, its callbacks are deleted, too.
+func deleteConfig(dc, env, app, key string, t int64) error {
+	err := backend.DeleteConfig(dc, env, app, key, t)
+	printLog(err, "Delete dc=%s, env=%s, app=%s, key=%s, time=%d", dc, env,
+		app, key, t)
 
 	// Delete the callbacks
-	if err == nil && t < 1 {
-		err = backend.DeleteCallback(vs["dc"], vs["env"], vs["app"], vs["key"], "")
+	if err == nil && key != "" && t < 1 {
+		err = backend.DeleteCallback(dc, env, app, key, "")
 	}
-	return renderError(w, err)
+	return err
 }
 
 // GetCallback returns all the callback notifications.
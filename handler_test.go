@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestGetConfigAsOf(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValues("bj", "dev", "a", "k1",
+		map[int64]string{100: "v1", 200: "v2", 300: "v3"})
+	backend.SetKeyValues("bj", "dev", "a", "k2", map[int64]string{250: "v1"})
+
+	cases := []struct {
+		key string
+		at  int64
+		vs  map[string]store.Version
+	}{
+		{"", 99, map[string]store.Version{}},
+		{"", 100, map[string]store.Version{"k1": {Time: 100, Value: "v1"}}},
+		{"", 260, map[string]store.Version{"k1": {Time: 200, Value: "v2"},
+			"k2": {Time: 250, Value: "v1"}}},
+		{"k1", 300, map[string]store.Version{"k1": {Time: 300, Value: "v3"}}},
+		{"k2", 200, map[string]store.Version{}},
+		{"k3", 300, map[string]store.Version{}},
+	}
+	for i, c := range cases {
+		vs, err := backend.GetConfigAsOf("bj", "dev", "a", c.key, c.at)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+		} else if len(vs) != len(c.vs) {
+			t.Errorf("%d: expected %v, but got %v", i, c.vs, vs)
+		} else {
+			for k, v := range c.vs {
+				if vs[k] != v {
+					t.Errorf("%d: expected %v, but got %v", i, c.vs, vs)
+				}
+			}
+		}
+	}
+
+	if kvs, version, err := getAppConfigAsOf("bj", "dev", "a", 260); err != nil ||
+		version != 250 || kvs["k1"] != "v2" || kvs["k2"] != "v1" {
+		t.Errorf("unexpected config %v at %d: %v", kvs, version, err)
+	}
+	if _, _, err := getAppConfigAsOf("bj", "dev", "a", 99); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound, but got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// reference is the reference to the value of another key, such as "${key}"
+// or "${app:key}", or an environment variable of the process, such as
+// "${env:NAME}".
+type reference struct {
+	App string // "env" for the environment variable
+	Key string
+}
+
+func (r reference) String() string {
+	return r.App + ":" + r.Key
+}
+
+// parseValue splits the value into the literals and the references, which
+// are called in turn. "$${" is escaped as the literal "${", and "${" without
+// the closing "}" is regarded as the literal.
+func parseValue(app, value string, literal func(string), ref func(reference)) {
+	for {
+		index := strings.Index(value, "${")
+		if index < 0 {
+			break
+		}
+
+		if index > 0 && value[index-1] == '$' {
+			literal(value[:index-1] + "${")
+			value = value[index+2:]
+			continue
+		}
+
+		end := strings.IndexByte(value[index+2:], '}')
+		if end < 0 {
+			break
+		}
+
+		literal(value[:index])
+		name := value[index+2 : index+2+end]
+		if i := strings.IndexByte(name, ':'); i > -1 {
+			ref(reference{App: name[:i], Key: name[i+1:]})
+		} else {
+			ref(reference{App: app, Key: name})
+		}
+		value = value[index+3+end:]
+	}
+	literal(value)
+}
+
+// getReferences returns the references to the keys in the value,
+// but not to the environment variables.
+func getReferences(app, value string) (refs []reference) {
+	parseValue(app, value, func(string) {}, func(r reference) {
+		if r.App != "env" {
+			refs = append(refs, r)
+		}
+	})
+	return
+}
+
+// resolver resolves the references in the values of the keys in dc and env
+// to the latest values of the referenced keys.
+type resolver struct {
+	dc    string
+	env   string
+	cache map[reference]string
+	stack []reference
+}
+
+func newResolver(dc, env string) *resolver {
+	return &resolver{dc: dc, env: env, cache: make(map[reference]string)}
+}
+
+// interpolationError returns the error of resolving the value of the key,
+// which is rendered as 422.
+func interpolationError(format string, args ...interface{}) error {
+	return http2.NewHTTPError(http.StatusUnprocessableEntity,
+		fmt.Errorf(format, args...))
+}
+
+// Resolve returns the value of the key of the app, the references in which
+// are replaced by the resolved values recursively.
+//
+// If a referenced key or environment variable does not exist, or there is
+// a cycle of the references, it returns an error, which is rendered as 422.
+func (rs *resolver) Resolve(app, key, value string) (string, error) {
+	self := reference{App: app, Key: key}
+	for i, r := range rs.stack {
+		if r == self {
+			cycle := make([]string, 0, len(rs.stack)-i+1)
+			for _, r := range rs.stack[i:] {
+				cycle = append(cycle, r.String())
+			}
+			cycle = append(cycle, self.String())
+			return "", interpolationError("the cycle of the references: %s",
+				strings.Join(cycle, " -> "))
+		}
+	}
+	rs.stack = append(rs.stack, self)
+	defer func() { rs.stack = rs.stack[:len(rs.stack)-1] }()
+
+	var err error
+	buf := bytes.NewBuffer(nil)
+	parseValue(app, value, func(s string) { buf.WriteString(s) },
+		func(r reference) {
+			if err == nil {
+				var v string
+				if v, err = rs.get(self, r); err == nil {
+					buf.WriteString(v)
+				}
+			}
+		})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// get returns the resolved value of the reference in the value of the key.
+func (rs *resolver) get(from, r reference) (string, error) {
+	if r.App == "env" {
+		if v, ok := os.LookupEnv(r.Key); ok {
+			return v, nil
+		}
+		return "", interpolationError(
+			"the environment variable '%s' referenced by '%s' does not exist",
+			r.Key, from)
+	}
+
+	if v, ok := rs.cache[r]; ok {
+		return v, nil
+	}
+
+	v, _, _, _, err := getAppValue(rs.dc, rs.env, r.App, r.Key)
+	if err == store.ErrNotFound {
+		return "", interpolationError(
+			"the key '%s' referenced by '%s' does not exist", r, from)
+	} else if err != nil {
+		return "", err
+	}
+
+	if v, err = rs.Resolve(r.App, r.Key, v); err != nil {
+		return "", err
+	}
+	rs.cache[r] = v
+	return v, nil
+}
+
+// resolveValues resolves the references in the values of the keys of the app.
+func resolveValues(dc, env, app string, kvs map[string]string) error {
+	rs := newResolver(dc, env)
+	for key, v := range kvs {
+		var err error
+		if kvs[key], err = rs.Resolve(app, key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyDependents notifies the callbacks of the keys in dc and env, which
+// reference the key of the app directly or indirectly.
+func notifyDependents(dc, env, app, key string) error {
+	apps, err := getAllApps(dc, env)
+	if err == store.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// Build the reverse graph of the references.
+	values := make(map[reference]string, 32)
+	dependents := make(map[reference][]reference, 8)
+	for _, _app := range apps {
+		kvs, _, err := getAppConfig(dc, env, _app)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for k, v := range kvs {
+			r := reference{App: _app, Key: k}
+			values[r] = v
+			for _, ref := range getReferences(_app, v) {
+				dependents[ref] = append(dependents[ref], r)
+			}
+		}
+	}
+	if len(dependents) == 0 {
+		return nil
+	}
+
+	changed := reference{App: app, Key: key}
+	visited := map[reference]bool{changed: true}
+	queue := []reference{changed}
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[r] {
+			if visited[d] {
+				continue
+			}
+			visited[d] = true
+			queue = append(queue, d)
+
+			err = notifyCallbacksIf(dc, env, d.App, d.Key, values[d], nil)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"syscall"
+	"time"
 
 	"github.com/xgfone/go-tools/net2/http2"
 	"github.com/xgfone/go-tools/signal2"
@@ -13,9 +14,20 @@ import (
 const version = "1.0.0"
 
 type option struct {
-	addr  string
-	conf  string
-	store string
+	addr     string
+	grpcAddr string
+	conf     string
+	store    string
+
+	watchInterval  time.Duration
+	trashRetention time.Duration
+
+	protectedEnvs  string
+	approvals      int
+	trustedProxies string
+
+	defaultDc  string
+	defaultEnv string
 
 	logfile  string
 	loglevel string
@@ -29,8 +41,23 @@ var (
 
 func init() {
 	flag.StringVar(&opt.addr, "addr", ":80", "The address to listen to.")
+	flag.StringVar(&opt.grpcAddr, "grpc-addr", "", "The address to listen to for gRPC. If empty, disable it.")
 	flag.StringVar(&opt.conf, "conf", "", "The configration information of the backend store.")
 	flag.StringVar(&opt.store, "store", "memory", "The backend store type, such as memory, zk, or mysql")
+	flag.DurationVar(&opt.watchInterval, "watch-interval", time.Second,
+		"The interval to poll the change events from the backend store.")
+	flag.DurationVar(&opt.trashRetention, "trash-retention", 7*24*time.Hour,
+		"The time to keep the deleted config in the trash. If 0, keep it forever.")
+	flag.StringVar(&opt.protectedEnvs, "protected-envs", "",
+		"The comma-separated envs, such as prod or dc1/prod, the change to which needs the approval.")
+	flag.IntVar(&opt.approvals, "approvals", 1,
+		"The number of the approvals required by a change to the protected envs.")
+	flag.StringVar(&opt.trustedProxies, "trusted-proxies", "",
+		"The IPs or CIDRs of the proxies, separated by comma, from which the header X-Appconfig-User is trusted. If empty, trust any client.")
+	flag.StringVar(&opt.defaultDc, "default-dc", "_default",
+		"The global default dc, to the default env of which the lookups fall back finally. If empty, disable it.")
+	flag.StringVar(&opt.defaultEnv, "default-env", "_default",
+		"The default env in the same dc, to which the lookups fall back. If empty, disable the fallback.")
 	flag.StringVar(&opt.logfile, "logfile", "", "the log file path.")
 	flag.StringVar(&opt.loglevel, "loglevel", "DEBUG", "the log level, such as DEBUG, INFO, etc.")
 	flag.BoolVar(&opt.version, "version", false, "Print the version and exit.")
@@ -44,12 +71,34 @@ func main() {
 	}
 
 	initLogger(opt.logfile, opt.loglevel)
+	setProtectedEnvs(opt.protectedEnvs, opt.approvals)
+	if err := setTrustedProxies(opt.trustedProxies); err != nil {
+		logger.Fatalf("failed to set the trusted proxies: %s", err)
+	}
+	setFallback(opt.defaultDc, opt.defaultEnv)
 
 	if err := InitStore(opt.store, opt.conf); err != nil {
 		logger.Fatalf("failed to initialize the backend store [%s]: %s",
 			opt.store, err)
 	}
 
+	// Watch the change events of the config.
+	go eventWatcher.Run(opt.watchInterval)
+
+	// Build and update the index to search the config.
+	go searchIndex.Run(opt.watchInterval)
+
+	// Purge the expired config in the trash.
+	go purgeExpiredTrashes(opt.trashRetention, time.Hour)
+
+	// Apply the due schedules.
+	go applySchedules(time.Second)
+
+	// Start gRPC Server.
+	if opt.grpcAddr != "" {
+		go serveGRPC(opt.grpcAddr)
+	}
+
 	// Wrap and handle the signal.
 	go signal2.HandleSignal(syscall.SIGTERM, syscall.SIGQUIT)
 
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// sensitiveMask replaces the values of the sensitive keys returned by
+// the admin APIs, such as the search, the values, the diffs, the drafts,
+// the changes, the schedules, the canaries and the promotion plan.
+const sensitiveMask = "******"
+
+// isSensitive reports whether the key is marked as sensitive by its metadata.
+func isSensitive(dc, env, app, key string) (bool, error) {
+	md, err := backend.GetMetadata(dc, env, app, key)
+	if err == store.ErrNotFound {
+		return false, nil
+	}
+	return md.Sensitive, err
+}
+
+// maskValues replaces the values of the key with sensitiveMask if the key
+// is sensitive.
+func maskValues(dc, env, app, key string, values map[int64]string) error {
+	if ok, err := isSensitive(dc, env, app, key); err != nil || !ok {
+		return err
+	}
+	for t := range values {
+		values[t] = sensitiveMask
+	}
+	return nil
+}
+
+// maskValue returns sensitiveMask instead of the value of the key if the key
+// is sensitive.
+func maskValue(dc, env, app, key, value string) (string, error) {
+	if ok, err := isSensitive(dc, env, app, key); err != nil || !ok {
+		return value, err
+	}
+	return sensitiveMask, nil
+}
+
+// GetMetadata returns the metadata of the key.
+func GetMetadata(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	md, err := backend.GetMetadata(vs["dc"], vs["env"], vs["app"], vs["key"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, md)
+}
+
+// SetMetadata replaces the metadata of the key by the JSON body, which has
+// the fields description, owner, labels, content_type and sensitive.
+//
+// If the key does not exist, it returns 404.
+func SetMetadata(w http.ResponseWriter, r *http.Request) error {
+	var md store.Metadata
+	body, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	} else if err = json.Unmarshal(body, &md); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	if md.ContentType != "" {
+		if _, _, err = mime.ParseMediaType(md.ContentType); err != nil {
+			return http2.String(w, http.StatusBadRequest,
+				"invalid content type '%s'", md.ContentType)
+		}
+	}
+
+	exists := make(map[string]bool, len(md.Labels))
+	for _, label := range md.Labels {
+		if label == "" {
+			return http2.String(w, http.StatusBadRequest, "the label is empty")
+		} else if exists[label] {
+			return http2.String(w, http.StatusBadRequest,
+				"duplicate label '%s'", label)
+		}
+		exists[label] = true
+	}
+
+	vs := mux.Vars(r)
+	md.Dc, md.Env, md.App, md.Key = vs["dc"], vs["env"], vs["app"], vs["key"]
+	_, _, err = backend.AppGetConfig(md.Dc, md.Env, md.App, md.Key, 0)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	md, err = backend.SetMetadata(md)
+	printLog(err, "Set the metadata: dc=%s, env=%s, app=%s, key=%s", md.Dc,
+		md.Env, md.App, md.Key)
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, md)
+}
+
+// DeleteMetadata deletes the metadata of the key.
+func DeleteMetadata(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	err := backend.DeleteMetadata(vs["dc"], vs["env"], vs["app"], vs["key"])
+	printLog(err, "Delete the metadata: dc=%s, env=%s, app=%s, key=%s",
+		vs["dc"], vs["env"], vs["app"], vs["key"])
+	return renderError(w, err)
+}
+
+// hasLabel reports whether the metadata has the label.
+func hasLabel(md store.Metadata, label string) bool {
+	for _, l := range md.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestGetKeysByMetadata(t *testing.T) {
+	backend = store.NewMemoryStore()
+	for _, key := range []string{"k1", "k2", "k3"} {
+		backend.SetKeyValue("bj", "dev", "a", key, "v")
+	}
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Owner: "infra", Labels: []string{"db"}})
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k2",
+		Owner: "web", Labels: []string{"db", "cache"}})
+
+	cases := []struct {
+		query string
+		keys  []string
+	}{
+		{"label=db", []string{"k1", "k2"}},
+		{"label=cache", []string{"k2"}},
+		{"owner=infra", []string{"k1"}},
+		{"label=db&owner=web", []string{"k2"}},
+		{"label=db&search=1", []string{"k1"}},
+		{"label=none", []string{}},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/v1/admin/bj/dev/a?"+c.query, nil)
+		handler.ServeHTTP(w, r)
+
+		var result struct {
+			Total int      `json:"total"`
+			Keys  []string `json:"keys"`
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, but got %d", c.query, w.Code)
+		} else if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+			t.Errorf("%s: %s", c.query, err)
+		} else if result.Total != len(c.keys) ||
+			!reflect.DeepEqual(append([]string{}, result.Keys...), c.keys) {
+			t.Errorf("%s: expected %v, but got %+v", c.query, c.keys, result)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/v1/admin/bj/dev/a?label=db&inherited=true", nil)
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("inherited: expected 400, but got %d", w.Code)
+	}
+}
+
+func TestSetMetadata(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v")
+
+	cases := []struct {
+		key, body string
+		code      int
+	}{
+		{"k1", `{"content_type": "application/json; charset=utf-8"}`, http.StatusOK},
+		{"k1", `{"content_type": "application/"}`, http.StatusBadRequest},
+		{"k1", `{"labels": ["db", ""]}`, http.StatusBadRequest},
+		{"k1", `{"labels": ["db", "db"]}`, http.StatusBadRequest},
+		{"k1", `{"labels": "db"}`, http.StatusBadRequest},
+		{"k2", `{"owner": "infra"}`, http.StatusNotFound},
+		{"k1", `{"owner": "infra", "labels": ["db"], "sensitive": true}`, http.StatusOK},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/v1/admin/bj/dev/a/"+c.key+"/metadata",
+			strings.NewReader(c.body))
+		handler.ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("%s: expected %d, but got %d", c.body, c.code, w.Code)
+		}
+	}
+
+	md, err := backend.GetMetadata("bj", "dev", "a", "k1")
+	if err != nil {
+		t.Fatal(err)
+	} else if md.Owner != "infra" || !md.Sensitive || md.ContentType != "" {
+		t.Errorf("unexpected metadata %+v", md)
+	}
+}
+
+func TestSensitiveValues(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.SetKeyValues("bj", "dev", "a", "k1", map[int64]string{
+		1: `{"password": "p1", "user": "u"}`,
+		2: `{"password": "p2", "user": "u"}`,
+	})
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Sensitive: true})
+
+	for _, path := range []string{
+		"/v1/admin/bj/dev/a/k1",
+		"/v2/admin/bj/dev/a/k1",
+		"/v1/admin/bj/dev/a/k1/diff",
+		"/v1/admin/bj/dev/a/k1/diff?type=unified",
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", path, nil)
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected 200, but got %d", path, w.Code)
+		} else if body := w.Body.String(); strings.Contains(body, "p1") ||
+			strings.Contains(body, "p2") || !strings.Contains(body, sensitiveMask) {
+			t.Errorf("%s: the values are not masked: %s", path, body)
+		}
+	}
+
+	// The app gets the real value.
+	if v, _, _, _, err := getKeyConfig("bj", "dev", "a", "k1",
+		configQuery{Raw: true}); err != nil || !strings.Contains(v, "p2") {
+		t.Errorf("unexpected value '%s': %v", v, err)
+	}
+}
+
+func TestSensitiveRecords(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValue("bj", "dev", "a", "k1", "p1")
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Sensitive: true})
+	backend.SetDraft("bj", "dev", "a", "k1", "p2")
+	backend.AddCanary(store.Canary{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "p2", Instances: []string{"i1"}})
+	backend.AddSchedule(store.Schedule{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "p2", At: 4102444800})
+	c, err := backend.AddChange(store.Change{Dc: "bj", Env: "dev", App: "a",
+		Key: "k1", Value: "p2", Requester: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct{ method, path, body string }{
+		{"GET", "/v1/draft/bj/dev/a", ""},
+		{"GET", "/v1/change/" + c.ID, ""},
+		{"GET", "/v1/change?dc=bj", ""},
+		{"GET", "/v1/schedule?dc=bj", ""},
+		{"GET", "/v1/canary/bj/dev/a", ""},
+		{"GET", "/v1/canary/bj/dev/a/k1", ""},
+		{"POST", "/v1/admin/promote", `{"source":{"dc":"bj","env":"dev","app":"a"},"target":{"dc":"bj","env":"test"},"dry_run":true}`},
+		{"POST", "/v1/admin/bj/dev/a/k1?at=4102444800", "p2"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(c.method, c.path, strings.NewReader(c.body))
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK && w.Code != http.StatusAccepted {
+			t.Errorf("%s %s: expected 200, but got %d: %s", c.method, c.path,
+				w.Code, w.Body)
+		} else if body := w.Body.String(); strings.Contains(body, "p1") ||
+			strings.Contains(body, "p2") || !strings.Contains(body, sensitiveMask) {
+			t.Errorf("%s %s: the values are not masked: %s", c.method, c.path, body)
+		}
+	}
+
+	// The proposed change of the protected env.
+	setProtectedEnvs("dev", 1)
+	defer setProtectedEnvs("", 0)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/v1/admin/bj/dev/a/k1", strings.NewReader("p2"))
+	r.Header.Set(identityHeader, "alice")
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Errorf("expected 202, but got %d: %s", w.Code, w.Body)
+	} else if body := w.Body.String(); strings.Contains(body, "p1") ||
+		strings.Contains(body, "p2") || !strings.Contains(body, sensitiveMask) {
+		t.Errorf("the change is not masked: %s", body)
+	}
+}
+
+func TestMetadataLifecycle(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v1")
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Owner: "infra"})
+
+	// Clone copies the metadata.
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/v1/admin/clone", strings.NewReader(
+		`{"source":{"dc":"bj","env":"dev"},"target":{"dc":"bj","env":"test"}}`))
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("clone: expected 200, but got %d: %s", w.Code, w.Body)
+	} else if md, err := backend.GetMetadata("bj", "test", "a", "k1"); err != nil ||
+		md.Owner != "infra" {
+		t.Errorf("clone: unexpected metadata %+v: %v", md, err)
+	}
+
+	// Trash and restore the metadata along with the key.
+	trash, err := removeConfig("bj", "dev", "a", "k1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err = backend.GetMetadata("bj", "dev", "a", "k1"); err !=
+		store.ErrNotFound {
+		t.Errorf("trash: expected no metadata, but got %v", err)
+	}
+	if _, err = backend.RestoreTrash(trash.ID); err != nil {
+		t.Fatal(err)
+	} else if md, err := backend.GetMetadata("bj", "dev", "a", "k1"); err != nil ||
+		md.Owner != "infra" {
+		t.Errorf("restore: unexpected metadata %+v: %v", md, err)
+	}
+
+	// Delete the metadata along with the key permanently.
+	if _, err = removeConfig("bj", "test", "", "", 0, true); err != nil {
+		t.Fatal(err)
+	} else if _, err = backend.GetMetadata("bj", "test", "a", "k1"); err !=
+		store.ErrNotFound {
+		t.Errorf("purge: expected no metadata, but got %v", err)
+	}
+}
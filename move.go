@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// notifyMoved notifies the callbacks of the moved keys of the app
+// with their latest values.
+func notifyMoved(dc, env, app string, keys ...string) error {
+	if len(keys) == 0 {
+		var err error
+		if keys, err = getAllKeys(dc, env, app); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		v, _, err := backend.AppGetConfig(dc, env, app, key, 0)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err = notifyCallbacks(dc, env, app, key, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMovable returns an error if the key of app, or the whole app if key
+// is "", cannot be moved, since its tags and pending changes stay behind.
+func checkMovable(dc, env, app, key string) error {
+	if err := checkUntagged(dc, env, app, key, 0); err != nil {
+		return err
+	}
+
+	changes, err := backend.GetChanges(dc, env, store.ChangePending)
+	if err != nil {
+		return err
+	}
+	for _, c := range changes {
+		if c.App == app && (key == "" || c.Key == key) {
+			return http2.NewHTTPError(http.StatusConflict, fmt.Errorf(
+				"the key '%s' has the pending change '%s'", c.Key, c.ID))
+		}
+	}
+	return nil
+}
+
+// MoveKey moves the key to another key, which is given by the query argument
+// to, the format of which is "app/key" or "key" in the same app.
+func MoveKey(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	to := http2.GetQuery(r.URL.Query(), "to")
+	toApp, toKey := app, to
+	if index := strings.IndexByte(to, '/'); index > -1 {
+		toApp, toKey = to[:index], to[index+1:]
+	}
+	if toApp == "" || toKey == "" || strings.Contains(toKey, "/") {
+		return http2.String(w, http.StatusBadRequest,
+			"the format of to must be app/key or key")
+	} else if toApp == app && toKey == key {
+		return http2.String(w, http.StatusBadRequest,
+			"the source is the same as the target")
+	} else if err := checkKeyName(toKey); err != nil {
+		return renderError(w, err)
+	} else if err := checkMovable(dc, env, app, key); err != nil {
+		return renderError(w, err)
+	}
+
+	err := backend.MoveConfig(dc, env, app, key, toApp, toKey)
+	printLog(err, "Move dc=%s, env=%s, app=%s, key=%s to app=%s, key=%s", dc,
+		env, app, key, toApp, toKey)
+	if err == nil {
+		err = notifyMoved(dc, env, toApp, toKey)
+	}
+	return renderError(w, err)
+}
+
+// MoveApp moves the whole app to another app, which is given by the query
+// argument to.
+func MoveApp(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	to := http2.GetQuery(r.URL.Query(), "to")
+	if to == "" || strings.Contains(to, "/") {
+		return http2.String(w, http.StatusBadRequest, "invalid app name")
+	} else if to == app {
+		return http2.String(w, http.StatusBadRequest,
+			"the source is the same as the target")
+	} else if err := checkMovable(dc, env, app, ""); err != nil {
+		return renderError(w, err)
+	}
+
+	err := backend.MoveConfig(dc, env, app, "", to, "")
+	printLog(err, "Move dc=%s, env=%s, app=%s to app=%s", dc, env, app, to)
+	if err == nil {
+		err = notifyMoved(dc, env, to)
+	}
+	return renderError(w, err)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestMoveConfig(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v1")
+	backend.SetKeyValue("bj", "dev", "a", "k2", "v1")
+	backend.SetDraft("bj", "dev", "a", "k1", "v2")
+	backend.AddCanary(store.Canary{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "v3", Instances: []string{"i1"}})
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Owner: "infra"})
+	backend.SetMetadata(store.Metadata{Dc: "bj", Env: "dev", App: "a", Key: "k2",
+		Labels: []string{"db"}})
+	backend.AddSchedule(store.Schedule{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "v4", At: 4102444800})
+
+	move := func(path string) int {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", path, nil)
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := move("/v1/admin/bj/dev/a/k1/move?to=k3"); code != http.StatusOK {
+		t.Fatalf("expected 200, but got %d", code)
+	}
+	if ds, _ := backend.GetDrafts("bj", "dev", "a"); len(ds) != 1 ||
+		ds[0].Key != "k3" || ds[0].Value != "v2" {
+		t.Errorf("unexpected drafts %+v", ds)
+	}
+	if c, err := backend.GetCanary("bj", "dev", "a", "k3"); err != nil ||
+		c.Key != "k3" || c.Value != "v3" {
+		t.Errorf("unexpected canary %+v: %v", c, err)
+	}
+	if md, err := backend.GetMetadata("bj", "dev", "a", "k3"); err != nil ||
+		md.Key != "k3" || md.Owner != "infra" {
+		t.Errorf("unexpected metadata %+v: %v", md, err)
+	}
+	if _, err := backend.GetMetadata("bj", "dev", "a", "k1"); err != store.ErrNotFound {
+		t.Errorf("expected no metadata of k1, but got %v", err)
+	}
+
+	// The target holding a draft has existed.
+	backend.SetDraft("bj", "dev", "a", "k4", "v1")
+	if code := move("/v1/admin/bj/dev/a/k2/move?to=k4"); code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, but got %d", code)
+	}
+	backend.DeleteDrafts("bj", "dev", "a", "k4")
+
+	if code := move("/v1/admin/bj/dev/a/move?to=b"); code != http.StatusOK {
+		t.Fatalf("expected 200, but got %d", code)
+	}
+	if mds, _ := backend.GetAllMetadata("bj", "dev", "a"); len(mds) != 0 {
+		t.Errorf("unexpected metadata %+v", mds)
+	}
+	mds, _ := backend.GetAllMetadata("bj", "dev", "b")
+	if len(mds) != 2 || mds["k2"].App != "b" || !hasLabel(mds["k2"], "db") {
+		t.Errorf("unexpected metadata %+v", mds)
+	}
+	if ss, _ := backend.GetSchedules("bj", "dev", "b"); len(ss) != 1 ||
+		ss[0].Key != "k3" || ss[0].Value != "v4" {
+		t.Errorf("unexpected schedules %+v", ss)
+	}
+	if cs, _ := backend.GetCanaries("bj", "dev", "b"); len(cs) != 1 ||
+		cs[0].App != "b" {
+		t.Errorf("unexpected canaries %+v", cs)
+	}
+
+	// The tags stay behind, so the tagged key cannot be moved.
+	backend.CreateTag("bj", "dev", "b", "t1")
+	if code := move("/v1/admin/bj/dev/b/k2/move?to=k5"); code != http.StatusConflict {
+		t.Errorf("expected 409, but got %d", code)
+	}
+	if code := move("/v1/admin/bj/dev/b/move?to=c"); code != http.StatusConflict {
+		t.Errorf("expected 409, but got %d", code)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// namespaceHeader is the response header of the namespace serving the value.
+const namespaceHeader = "X-Appconfig-Namespace"
+
+// getAppValue returns the latest value of the key of the app like
+// getLatestValue, but falls back to the namespaces, on which the app depends,
+// in turn if the app has no the key.
+//
+// namespace is the namespace serving the value, or "" if served by the app.
+func getAppValue(dc, env, app, key string) (v string, version int64,
+	level, namespace string, err error) {
+	v, version, level, err = getLatestValue(dc, env, app, key)
+	if err != store.ErrNotFound {
+		return
+	}
+
+	namespaces, err := backend.GetDependencies(dc, env, app)
+	if err != nil {
+		return
+	}
+	for _, ns := range namespaces {
+		v, version, level, err = getLatestValue(dc, env, ns, key)
+		if err != store.ErrNotFound {
+			return v, version, level, ns, err
+		}
+	}
+	return "", 0, "", "", store.ErrNotFound
+}
+
+// getEffectiveConfig returns the latest values of all the keys of the app
+// like getInheritedConfig, including the keys shared by the namespaces,
+// on which the app depends. The app overrides the namespaces, and the former
+// namespace overrides the latter.
+func getEffectiveConfig(dc, env, app string) (map[string]string, int64,
+	error) {
+	namespaces, err := backend.GetDependencies(dc, env, app)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var version int64
+	kvs := make(map[string]string, 32)
+	apps := append([]string{app}, namespaces...)
+	for i := len(apps) - 1; i >= 0; i-- {
+		_kvs, v, err := getInheritedConfig(dc, env, apps[i])
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		for k, v := range _kvs {
+			kvs[k] = v
+		}
+		if v > version {
+			version = v
+		}
+	}
+
+	if len(kvs) == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+	return kvs, version, nil
+}
+
+// notifyConsumers notifies the callbacks of the key in the apps, which depend
+// on the namespace and get the value of the key from it.
+func notifyConsumers(dc, env, namespace, key, value string) error {
+	apps, err := backend.GetDependents(dc, env, namespace)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		_, _, _, ns, err := getAppValue(dc, env, app, key)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		} else if ns != namespace {
+			continue
+		}
+
+		if err = notifyCallbacksIf(dc, env, app, key, value, nil); err != nil {
+			return err
+		}
+		if err = notifyDependents(dc, env, app, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDependencies returns the namespaces, on which the app depends.
+func GetDependencies(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	namespaces, err := backend.GetDependencies(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"namespaces": namespaces})
+}
+
+// GetDependents returns the apps depending on the namespace.
+func GetDependents(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	apps, err := backend.GetDependents(vs["dc"], vs["env"], vs["namespace"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if apps == nil {
+		apps = []string{}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"apps": apps})
+}
+
+// SetDependencies replaces the namespaces, on which the app depends, which are
+// given by the body, then notifies the callbacks of the keys of the app,
+// the values of which are changed.
+func SetDependencies(w http.ResponseWriter, r *http.Request) error {
+	var req struct {
+		Namespaces []string `json:"namespaces"`
+	}
+
+	body, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	} else if err = json.Unmarshal(body, &req); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	app := mux.Vars(r)["app"]
+	exists := make(map[string]bool, len(req.Namespaces))
+	for _, ns := range req.Namespaces {
+		if ns == "" || strings.Contains(ns, "/") {
+			return http2.String(w, http.StatusBadRequest,
+				"invalid namespace '%s'", ns)
+		} else if ns == app {
+			return http2.String(w, http.StatusBadRequest,
+				"the app cannot depend on itself")
+		} else if exists[ns] {
+			return http2.String(w, http.StatusBadRequest,
+				"duplicate namespace '%s'", ns)
+		}
+		exists[ns] = true
+	}
+	return setDependencies(w, r, req.Namespaces)
+}
+
+// DeleteDependencies deletes all the dependencies of the app.
+func DeleteDependencies(w http.ResponseWriter, r *http.Request) error {
+	return setDependencies(w, r, nil)
+}
+
+func setDependencies(w http.ResponseWriter, r *http.Request,
+	namespaces []string) error {
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	olds, _, err := getEffectiveConfig(dc, env, app)
+	if err != nil && err != store.ErrNotFound {
+		return renderError(w, err)
+	}
+
+	err = backend.SetDependencies(dc, env, app, namespaces)
+	printLog(err, "Set the dependencies: dc=%s, env=%s, app=%s, namespaces=%v",
+		dc, env, app, namespaces)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	news, _, err := getEffectiveConfig(dc, env, app)
+	if err == store.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return renderError(w, err)
+	}
+
+	for _, key := range sortedKeys(news) {
+		if v, ok := olds[key]; ok && v == news[key] {
+			continue
+		}
+		if err = notifyCallbacksIf(dc, env, app, key, news[key], nil); err != nil {
+			return renderError(w, err)
+		}
+		if err = notifyDependents(dc, env, app, key); err != nil {
+			return renderError(w, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// apiParam is a query argument of the operation.
+type apiParam struct {
+	Name        string
+	Type        string // "string", "integer" or "boolean"
+	Description string
+	Required    bool
+}
+
+// apiText is the content type of the successful response, which is not JSON.
+type apiText string
+
+// apiOperation is an operation of the API v1 described by the OpenAPI document.
+type apiOperation struct {
+	Method  string
+	Path    string // The path template relative to "/v1".
+	Summary string
+	Query   []apiParam
+
+	// Body is the content type of the request body, or "" if no body.
+	Body string
+
+	// Result is the sample of the successful response, the schema of which
+	// is reflected from it. If nil, the response has no body.
+	Result interface{}
+}
+
+// The query arguments shared by the operations.
+var (
+	qPage     = apiParam{"page", "integer", "The page number, which begins at 1.", false}
+	qSize     = apiParam{"size", "integer", "The number of the items in one page, which is 20 by default.", false}
+	qSearch   = apiParam{"search", "string", "The substring to filter the names.", false}
+	qFrom     = apiParam{"from", "integer", "The start version.", false}
+	qTo       = apiParam{"to", "integer", "The end version.", false}
+	qTime     = apiParam{"time", "integer", "The version of the value.", false}
+	qAt       = apiParam{"at", "integer", "The unixstamp, at which the values are effective.", false}
+	qTag      = apiParam{"tag", "string", "The tag pinning the versions of the keys.", false}
+	qRaw      = apiParam{"raw", "boolean", "Not resolve the references in the values.", false}
+	qDryRun   = apiParam{"dry_run", "boolean", "Only return the plan without applying it.", false}
+	qPurge    = apiParam{"purge", "boolean", "Delete the configuration permanently instead of moving it into the trash.", false}
+	qDc       = apiParam{"dc", "string", "Filter by the dc.", false}
+	qEnv      = apiParam{"env", "string", "Filter by the env.", false}
+	qFormat   = apiParam{"format", "string", "The format of the document, such as json, yaml, toml, ini or properties.", false}
+	qMoveTo   = apiParam{"to", "string", "The new name.", true}
+	qReqTime  = apiParam{"time", "integer", "The unixstamp, to which the app is rolled back.", true}
+	qAgainst  = apiParam{"against", "string", "The dc/env to compare against.", true}
+	qCallback = apiParam{"id", "string", "The id of the callback to be deleted. If missing, delete all.", false}
+)
+
+// The samples of the successful responses.
+var (
+	rChange = map[string]interface{}{"change": store.Change{}, "diff": "", "stale": false}
+	rCanary = map[string]interface{}{"canary": store.Canary{}}
+	rTag    = map[string]interface{}{"tag": store.Tag{}}
+	rTrash  = map[string]interface{}{"trash": store.Trash{}}
+	rText   = apiText("text/plain")
+)
+
+// apiOperations is all the operations of the API v1 registered in handler.go.
+//
+// When registering a new route, describe it here, or TestOpenAPI fails.
+var apiOperations = []apiOperation{
+	// App Config
+	{Method: "GET", Path: "/app/{dc}/{env}/{app}", Summary: "App get the whole configuration of an app",
+		Query: []apiParam{qAt, qTag, qRaw, qFormat,
+			{"nested", "boolean", "Render the dotted keys as the nested document.", false}},
+		Result: map[string]string{}},
+	{Method: "GET", Path: "/app/{dc}/{env}/{app}/{key}", Summary: "App get the configuration of a key",
+		Query: []apiParam{qTime, qAt, qTag, qRaw,
+			{"wait", "string", "The duration to wait for a newer version than since, such as 30s.", false},
+			{"since", "integer", "The version known by the app.", false}},
+		Result: rText},
+
+	// Stream
+	{Method: "GET", Path: "/stream/{dc}/{env}", Summary: "Stream the changes of the configuration in dc and env",
+		Query:  []apiParam{{"last_event_id", "integer", "The id of the last received event.", false}},
+		Result: apiText("text/event-stream")},
+	{Method: "GET", Path: "/stream/{dc}/{env}/{app}", Summary: "Stream the changes of the configuration of an app",
+		Query:  []apiParam{{"last_event_id", "integer", "The id of the last received event.", false}},
+		Result: apiText("text/event-stream")},
+
+	// Admin Config
+	{Method: "POST", Path: "/admin", Summary: "Admin create dc and env",
+		Query: []apiParam{{"dc", "string", "The dc.", true}, {"env", "string", "The env.", true}}},
+	{Method: "GET", Path: "/admin", Summary: "Admin get all dc and env",
+		Result: map[string][]string{}},
+	{Method: "POST", Path: "/admin/promote", Summary: "Admin promote the configuration from an env to another",
+		Body: "application/json",
+		Result: map[string]interface{}{"dry_run": false, "fingerprint": "",
+			"plan": []promoteItem{}}},
+	{Method: "POST", Path: "/admin/clone", Summary: "Admin clone the configuration from an env to another",
+		Body:   "application/json",
+		Result: map[string]interface{}{"apps": 0, "keys": 0, "values": 0, "callbacks": 0}},
+	{Method: "GET", Path: "/admin/search", Summary: "Admin search the keys by the names or the latest values",
+		Query: []apiParam{{"q", "string", "The substring, or the regular expression if regex is true.", true},
+			{"in", "string", "Only search the names if name, or the values if value.", false},
+			{"regex", "boolean", "Regard q as the regular expression.", false},
+			{"dc", "string", "Only search the dc.", false},
+			{"env", "string", "Only search the env.", false},
+			{"app", "string", "Only search the app.", false},
+			qPage, qSize},
+		Result: map[string]interface{}{"total": int64(0), "results": []searchResult{}}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}", Summary: "Admin import the configuration file into an app",
+		Query: []apiParam{qFormat, qDryRun,
+			{"sep", "string", "The separator to flatten the nested keys, which is . by default.", false}},
+		Body: "application/octet-stream",
+		Result: map[string]interface{}{"dry_run": false, "added": []string{},
+			"changed": []string{}, "unchanged": []string{}}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/rollback", Summary: "Admin rollback an app to a previous time",
+		Query: []apiParam{qReqTime, qDryRun},
+		Result: map[string]interface{}{"dry_run": false, "changed": map[string]int64{},
+			"unchanged": []string{}, "skipped": []string{}}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/move", Summary: "Admin rename an app",
+		Query: []apiParam{qMoveTo}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/publish", Summary: "Admin publish the drafts of an app",
+		Result: map[string]interface{}{"version": int64(0), "keys": []string{}}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/{key}", Summary: "Admin upload the key-value configuration",
+		Query: []apiParam{qAt,
+			{"draft", "boolean", "Save the value as the draft.", false}},
+		Body: "text/plain"},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/{key}/rollback", Summary: "Admin rollback a key to a previous version",
+		Query: []apiParam{qTime,
+			{"steps", "integer", "The number of the versions to roll back, which is 1 by default.", false}},
+		Result: map[string]interface{}{"version": int64(0), "target": int64(0)}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/{key}/move", Summary: "Admin rename a key",
+		Query: []apiParam{qMoveTo}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}/{key}/rollback", Summary: "Admin list the rollbacks of a key",
+		Result: map[string]interface{}{"rollbacks": []store.Rollback{}}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}/{key}/metadata", Summary: "Admin get the metadata of a key",
+		Result: store.Metadata{}},
+	{Method: "POST", Path: "/admin/{dc}/{env}/{app}/{key}/metadata", Summary: "Admin set the metadata of a key",
+		Body: "application/json", Result: store.Metadata{}},
+	{Method: "DELETE", Path: "/admin/{dc}/{env}/{app}/{key}/metadata", Summary: "Admin delete the metadata of a key"},
+	{Method: "GET", Path: "/admin/{dc}/{env}", Summary: "Admin get all apps in dc and env",
+		Query:  []apiParam{qPage, qSize, qSearch},
+		Result: map[string]interface{}{"total": int64(0), "apps": []string{}}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}", Summary: "Admin get all keys of an app in dc and env",
+		Query: []apiParam{qPage, qSize, qSearch,
+			{"inherited", "boolean", "Include the keys inherited by the fallback chain.", false},
+			{"label", "string", "Only the keys with the label in the metadata.", false},
+			{"owner", "string", "Only the keys owned by the team in the metadata.", false}},
+		Result: map[string]interface{}{"total": int64(0), "keys": []string{},
+			"configs": []inheritedConfig{}}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}/diff", Summary: "Admin compare an app against another dc and env",
+		Query: []apiParam{qAgainst},
+		Result: map[string]interface{}{"against": "", "only_self": []string{},
+			"only_against": []string{}, "different": []string{}, "same": []string{}}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}/{key}", Summary: "Admin get all values of the specified key",
+		Query:  []apiParam{qPage, qSize, qFrom, qTo},
+		Result: map[string]interface{}{"total": int64(0), "values": map[string]string{}}},
+	{Method: "GET", Path: "/admin/{dc}/{env}/{app}/{key}/diff", Summary: "Admin compare two versions of a key",
+		Query: []apiParam{qFrom, qTo,
+			{"type", "string", "The type of the diff, which is unified or structural.", false}},
+		Result: map[string]interface{}{"from": int64(0), "to": int64(0), "type": "",
+			"diff": "", "changes": []diffChange{}}},
+	{Method: "DELETE", Path: "/admin/{dc}", Summary: "Admin delete the whole dc",
+		Query: []apiParam{qPurge}, Result: rTrash},
+	{Method: "DELETE", Path: "/admin/{dc}/{env}", Summary: "Admin delete the whole env in dc",
+		Query: []apiParam{qPurge}, Result: rTrash},
+	{Method: "DELETE", Path: "/admin/{dc}/{env}/{app}", Summary: "Admin delete the whole app in dc and env",
+		Query: []apiParam{qPurge}, Result: rTrash},
+	{Method: "DELETE", Path: "/admin/{dc}/{env}/{app}/{key}", Summary: "Admin delete the key or a version of it",
+		Query: []apiParam{qTime, qPurge}, Result: rTrash},
+
+	// Change Approval
+	{Method: "GET", Path: "/change", Summary: "Admin list the changes",
+		Query: []apiParam{qDc, qEnv,
+			{"status", "string", "Filter by the status, which is pending, approved or rejected.", false}},
+		Result: map[string]interface{}{"changes": []store.Change{}}},
+	{Method: "GET", Path: "/change/{id}", Summary: "Admin get a change", Result: rChange},
+	{Method: "POST", Path: "/change/{id}/approve", Summary: "Admin approve a change", Result: rChange},
+	{Method: "POST", Path: "/change/{id}/reject", Summary: "Admin reject a change",
+		Body: "text/plain", Result: rChange},
+
+	// Namespace Dependency
+	{Method: "GET", Path: "/dependency/{dc}/{env}/{app}", Summary: "Admin get the namespaces of an app",
+		Result: map[string]interface{}{"namespaces": []string{}}},
+	{Method: "POST", Path: "/dependency/{dc}/{env}/{app}", Summary: "Admin set the namespaces of an app",
+		Body: "application/json"},
+	{Method: "DELETE", Path: "/dependency/{dc}/{env}/{app}", Summary: "Admin delete the namespaces of an app"},
+	{Method: "GET", Path: "/dependency/{dc}/{env}/{namespace}/dependents", Summary: "Admin list the apps depending on a namespace",
+		Result: map[string]interface{}{"apps": []string{}}},
+
+	// Canary
+	{Method: "GET", Path: "/canary/{dc}/{env}/{app}", Summary: "Admin list the canaries of an app",
+		Result: map[string]interface{}{"canaries": []store.Canary{}}},
+	{Method: "POST", Path: "/canary/{dc}/{env}/{app}/{key}", Summary: "Admin create a canary of a key",
+		Body: "application/json", Result: rCanary},
+	{Method: "GET", Path: "/canary/{dc}/{env}/{app}/{key}", Summary: "Admin get the canary of a key",
+		Result: rCanary},
+	{Method: "DELETE", Path: "/canary/{dc}/{env}/{app}/{key}", Summary: "Admin abort the canary of a key"},
+	{Method: "POST", Path: "/canary/{dc}/{env}/{app}/{key}/widen", Summary: "Admin widen the canary of a key",
+		Body: "application/json", Result: rCanary},
+	{Method: "POST", Path: "/canary/{dc}/{env}/{app}/{key}/promote", Summary: "Admin promote the canary of a key"},
+
+	// Schedule
+	{Method: "GET", Path: "/schedule", Summary: "Admin list the schedules",
+		Query:  []apiParam{qDc, qEnv, {"app", "string", "Filter by the app.", false}},
+		Result: map[string]interface{}{"schedules": []store.Schedule{}}},
+	{Method: "DELETE", Path: "/schedule/{id}", Summary: "Admin cancel a schedule"},
+
+	// Draft
+	{Method: "GET", Path: "/draft/{dc}/{env}/{app}", Summary: "Admin list the drafts of an app",
+		Result: map[string]interface{}{"drafts": []store.Draft{}}},
+	{Method: "DELETE", Path: "/draft/{dc}/{env}/{app}", Summary: "Admin discard all the drafts of an app"},
+	{Method: "DELETE", Path: "/draft/{dc}/{env}/{app}/{key}", Summary: "Admin discard the draft of a key"},
+
+	// Tag
+	{Method: "GET", Path: "/tag/{dc}/{env}/{app}", Summary: "Admin list the tags of an app",
+		Result: map[string]interface{}{"tags": []store.Tag{}}},
+	{Method: "POST", Path: "/tag/{dc}/{env}/{app}/{name}", Summary: "Admin create a tag of an app",
+		Result: rTag},
+	{Method: "GET", Path: "/tag/{dc}/{env}/{app}/{name}", Summary: "Admin get a tag of an app",
+		Result: rTag},
+	{Method: "DELETE", Path: "/tag/{dc}/{env}/{app}/{name}", Summary: "Admin delete a tag of an app"},
+
+	// Trash
+	{Method: "GET", Path: "/trash", Summary: "Admin list the trash",
+		Result: map[string]interface{}{"trash": []store.Trash{}}},
+	{Method: "POST", Path: "/trash/{id}", Summary: "Admin restore the configuration from the trash",
+		Result: rTrash},
+	{Method: "DELETE", Path: "/trash/{id}", Summary: "Admin purge the configuration in the trash"},
+
+	// Callback Notification
+	{Method: "GET", Path: "/callback/{dc}/{env}/{app}/{key}", Summary: "Get all the callbacks of a key",
+		Result: map[string]interface{}{"callback": map[string]string{}}},
+	{Method: "POST", Path: "/callback/{dc}/{env}/{app}/{key}/{id}", Summary: "Add the callback to watch a key",
+		Body: "text/plain"},
+	{Method: "DELETE", Path: "/callback/{dc}/{env}/{app}/{key}", Summary: "Delete the callbacks of a key",
+		Query: []apiParam{qCallback}},
+	{Method: "GET", Path: "/callback/{dc}/{env}/{app}/{key}/{id}", Summary: "Get the results of the callback notification",
+		Result: map[string]interface{}{"result": [][3]string{}}},
+
+	// OpenAPI
+	{Method: "GET", Path: "/openapi.json", Summary: "Get the OpenAPI document of the API v1",
+		Result: map[string]interface{}{}},
+}
+
+// apiPathParams is the descriptions of the path variables.
+var apiPathParams = map[string]string{
+	"dc":        "The data center.",
+	"env":       "The environment.",
+	"app":       "The app.",
+	"key":       "The key.",
+	"id":        "The id.",
+	"name":      "The name of the tag.",
+	"namespace": "The namespace, which is an app shared by other apps.",
+}
+
+var pathVarRegexp = regexp.MustCompile(`\{(\w+)\}`)
+
+// typeSchema returns the JSON schema of the type.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem()),
+			"minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object",
+			"additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+
+			name := f.Name
+			if tag := f.Tag.Get("json"); tag == "-" {
+				continue
+			} else if tag = strings.Split(tag, ",")[0]; tag != "" {
+				name = tag
+			}
+			props[name] = typeSchema(f.Type)
+		}
+		return map[string]interface{}{"type": "object", "properties": props}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// valueSchema returns the JSON schema of the sample value. For the map with
+// the interface elements, the properties are reflected from the elements.
+func valueSchema(v interface{}) map[string]interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) == 0 {
+		return typeSchema(reflect.TypeOf(v))
+	}
+
+	props := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		props[k] = valueSchema(v)
+	}
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+// getOpenAPI returns the OpenAPI 3 document of the API v1.
+func getOpenAPI() map[string]interface{} {
+	paths := make(map[string]interface{}, len(apiOperations))
+	for _, op := range apiOperations {
+		var params []interface{}
+		for _, m := range pathVarRegexp.FindAllStringSubmatch(op.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":        m[1],
+				"in":          "path",
+				"required":    true,
+				"description": apiPathParams[m[1]],
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range op.Query {
+			params = append(params, map[string]interface{}{
+				"name":        q.Name,
+				"in":          "query",
+				"required":    q.Required,
+				"description": q.Description,
+				"schema":      map[string]interface{}{"type": q.Type},
+			})
+		}
+
+		ok := map[string]interface{}{"description": "OK"}
+		switch result := op.Result.(type) {
+		case nil:
+		case apiText:
+			ok["content"] = map[string]interface{}{string(result): map[string]interface{}{
+				"schema": map[string]interface{}{"type": "string"}}}
+		default:
+			ok["content"] = map[string]interface{}{"application/json": map[string]interface{}{
+				"schema": valueSchema(result)}}
+		}
+
+		operation := map[string]interface{}{
+			"summary": op.Summary,
+			"responses": map[string]interface{}{
+				"200": ok,
+				"default": map[string]interface{}{
+					"description": "The error, the body of which is the plain text or empty.",
+				},
+			},
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+		if op.Body != "" {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{op.Body: map[string]interface{}{
+					"schema": map[string]interface{}{}}},
+			}
+		}
+
+		path, _ := paths[op.Path].(map[string]interface{})
+		if path == nil {
+			path = make(map[string]interface{}, 4)
+			paths[op.Path] = path
+		}
+		path[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "appconfig",
+			"version": "v1",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": "/v1"}},
+		"paths":   paths,
+	}
+}
+
+// GetOpenAPI returns the OpenAPI 3 document of the API v1.
+func GetOpenAPI(w http.ResponseWriter, r *http.Request) error {
+	return http2.JSON(w, http.StatusOK, getOpenAPI())
+}
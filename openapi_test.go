@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPI(t *testing.T) {
+	described := make(map[string]bool, len(apiOperations))
+	for _, op := range apiOperations {
+		described[op.Method+" "+op.Path] = true
+	}
+
+	handler.(*mux.Router).Walk(func(route *mux.Route, router *mux.Router,
+		ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(path, "/v1/") {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path = strings.TrimPrefix(path, "/v1")
+		for _, method := range methods {
+			if !described[method+" "+path] {
+				t.Errorf("the route '%s %s' is not described in apiOperations",
+					method, path)
+			}
+			delete(described, method+" "+path)
+		}
+		return nil
+	})
+
+	for op := range described {
+		t.Errorf("the described operation '%s' is not registered", op)
+	}
+
+	paths := getOpenAPI()["paths"].(map[string]interface{})
+	op := paths["/admin/{dc}/{env}/{app}/{key}"].(map[string]interface{})["get"]
+	params := op.(map[string]interface{})["parameters"].([]interface{})
+	if len(params) != 8 {
+		t.Errorf("expected 4 path variables and 4 query arguments, got %d",
+			len(params))
+	}
+}
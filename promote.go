@@ -0,0 +1,190 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// promoteRequest is the request to promote the config of the app
+// from the source dc and env to the target.
+//
+// If Target.App is "", it's the same as Source.App. If Keys is empty,
+// promote all the keys of the app.
+type promoteRequest struct {
+	Source struct {
+		Dc  string `json:"dc"`
+		Env string `json:"env"`
+		App string `json:"app"`
+	} `json:"source"`
+
+	Target struct {
+		Dc  string `json:"dc"`
+		Env string `json:"env"`
+		App string `json:"app"`
+	} `json:"target"`
+
+	Keys   []string `json:"keys"`
+	DryRun bool     `json:"dry_run"`
+
+	// Fingerprint is the fingerprint of the plan returned by the dry run.
+	// If given, refuse to promote when the plan has been changed.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// promoteItem is the plan to promote a key.
+type promoteItem struct {
+	Key string `json:"key"`
+
+	// Action is one of "add", "change" and "unchanged".
+	Action string `json:"action"`
+	Value  string `json:"value"`
+
+	// SourceVersion and TargetVersion are the latest versions of the key
+	// in the source and the target. If the key does not exist in the target,
+	// TargetVersion is 0.
+	SourceVersion int64 `json:"source_version"`
+	TargetVersion int64 `json:"target_version"`
+}
+
+// errNoSourceKey is returned when the key to be promoted does not exist
+// in the source.
+type errNoSourceKey string
+
+func (e errNoSourceKey) Error() string {
+	return fmt.Sprintf("no key '%s' in the source", string(e))
+}
+
+// getPromotePlan returns the plan to promote the keys, and the fingerprint
+// of the plan, which is computed over the latest versions and values
+// of the keys in the source and the target.
+func getPromotePlan(req promoteRequest) ([]promoteItem, string, error) {
+	src, dst := req.Source, req.Target
+
+	keys := req.Keys
+	if len(keys) == 0 {
+		var err error
+		if keys, err = getAllKeys(src.Dc, src.Env, src.App); err != nil {
+			return nil, "", err
+		}
+	}
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+
+	hash := sha1.New()
+	plan := make([]promoteItem, 0, len(keys))
+	for _, key := range keys {
+		if err := checkKeyName(key); err != nil {
+			return nil, "", err
+		}
+
+		v, sv, err := backend.AppGetConfig(src.Dc, src.Env, src.App, key, 0)
+		if err == store.ErrNotFound {
+			return nil, "", errNoSourceKey(key)
+		} else if err != nil {
+			return nil, "", err
+		}
+
+		item := promoteItem{Key: key, Value: v, SourceVersion: sv}
+		old, tv, err := backend.AppGetConfig(dst.Dc, dst.Env, dst.App, key, 0)
+		switch {
+		case err == store.ErrNotFound:
+			item.Action = "add"
+		case err != nil:
+			return nil, "", err
+		case old == v:
+			item.Action = "unchanged"
+			item.TargetVersion = tv
+		default:
+			item.Action = "change"
+			item.TargetVersion = tv
+		}
+
+		plan = append(plan, item)
+		fmt.Fprintf(hash, "%q %d %q %d %q\n", key, sv, v, item.TargetVersion, old)
+	}
+
+	return plan, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// PromoteConfig copies the latest values of the keys of the app from
+// the source dc and env to the target, and notifies the callbacks of
+// the target.
+//
+// In the dry run, it only returns the plan and its fingerprint.
+func PromoteConfig(w http.ResponseWriter, r *http.Request) error {
+	body, err := http2.GetBody(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	var req promoteRequest
+	if err = json.Unmarshal(body, &req); err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	src, dst := req.Source, &req.Target
+	if src.Dc == "" || src.Env == "" || src.App == "" {
+		return http2.String(w, http.StatusBadRequest,
+			"missing the source dc, env or app")
+	} else if dst.Dc == "" || dst.Env == "" {
+		return http2.String(w, http.StatusBadRequest,
+			"missing the target dc or env")
+	}
+	if dst.App == "" {
+		dst.App = src.App
+	}
+	if src.Dc == dst.Dc && src.Env == dst.Env && src.App == dst.App {
+		return http2.String(w, http.StatusBadRequest,
+			"the source is the same as the target")
+	} else if !req.DryRun && isProtected(dst.Dc, dst.Env) {
+		return renderProtected(w, dst.Dc, dst.Env)
+	}
+
+	plan, fingerprint, err := getPromotePlan(req)
+	if e, ok := err.(errNoSourceKey); ok {
+		return http2.String(w, http.StatusNotFound, "no key '%s' in the source",
+			string(e))
+	} else if err != nil {
+		return renderError(w, err)
+	}
+
+	if req.Fingerprint != "" && req.Fingerprint != fingerprint {
+		return http2.String(w, http.StatusConflict,
+			"the source or target has been changed since the plan")
+	}
+
+	if !req.DryRun {
+		for _, item := range plan {
+			if item.Action == "unchanged" {
+				continue
+			}
+
+			err = setKeyValue(dst.Dc, dst.Env, dst.App, item.Key, item.Value)
+			printLog(err, "Promote dc=%s, env=%s, app=%s, key=%s to dc=%s, env=%s, app=%s",
+				src.Dc, src.Env, src.App, item.Key, dst.Dc, dst.Env, dst.App)
+			if err != nil {
+				return renderError(w, err)
+			}
+		}
+	}
+
+	for i, item := range plan {
+		if plan[i].Value, err = maskValue(src.Dc, src.Env, src.App, item.Key,
+			item.Value); err != nil {
+			return renderError(w, err)
+		}
+	}
+
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run":     req.DryRun,
+		"fingerprint": fingerprint,
+		"plan":        plan,
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// getValues returns all the values of the key. The key of the result is
+// the version.
+//
+// If to is greater than 0, only return the values not after it.
+func getValues(dc, env, app, key string, to int64) (map[int64]string, error) {
+	const size = 100
+	values := make(map[int64]string, size)
+	for page := int64(1); ; page++ {
+		_, vs, err := backend.GetAllValues(dc, env, app, key, page, size, 0, to)
+		if err != nil {
+			return nil, err
+		}
+		for version, v := range vs {
+			values[version] = v
+		}
+		if int64(len(vs)) < size {
+			return values, nil
+		}
+	}
+}
+
+// getVersions returns all the versions of the key in the ascending order.
+//
+// If to is greater than 0, only return the versions not after it.
+func getVersions(dc, env, app, key string, to int64) ([]int64, error) {
+	values, err := getValues(dc, env, app, key, to)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int64, 0, len(values))
+	for version := range values {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// rollbackKey re-publishes the value of the key at _time as a new version,
+// then notifies the apps watching the key that the value has been changed.
+func rollbackKey(dc, env, app, key string, _time int64) (int64, error) {
+	value, _, err := backend.AppGetConfig(dc, env, app, key, _time)
+	if err != nil {
+		return 0, err
+	}
+
+	version, err := backend.RollbackKey(dc, env, app, key, _time)
+	printLog(err, "Rollback dc=%s, env=%s, app=%s, key=%s to time=%d", dc, env,
+		app, key, _time)
+	if err != nil {
+		return 0, err
+	}
+	return version, notifyCallbacks(dc, env, app, key, value)
+}
+
+// RollbackKey rolls the key back to the version given by the query argument
+// time, or to the version steps back from the latest.
+func RollbackKey(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	t, err := http2.GetQueryInt64(query, "time")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	steps, err := http2.GetQueryInt64(query, "steps")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	if (t > 0) == (steps > 0) {
+		return http2.String(w, http.StatusBadRequest,
+			"either time or steps must be given")
+	}
+
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	key := vs["key"]
+	if isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	if steps > 0 {
+		versions, err := getVersions(dc, env, app, key, 0)
+		if err != nil {
+			return renderError(w, err)
+		}
+		if int64(len(versions)) <= steps {
+			return http2.String(w, http.StatusNotFound, "not enough versions")
+		}
+		t = versions[int64(len(versions))-1-steps]
+	}
+
+	version, err := rollbackKey(dc, env, app, key, t)
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"version": version, "target": t})
+}
+
+// RollbackApp rolls every key of the app back to its state at the time given
+// by the query argument time.
+//
+// The keys, the value of which has not been changed since then, are unchanged,
+// and the keys created after then are skipped.
+func RollbackApp(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	t, err := http2.GetQueryInt64(query, "time")
+	if err != nil || t < 1 {
+		return http2.String(w, http.StatusBadRequest, "invalid time")
+	}
+
+	dryRun, err := getQueryBool(query, "dry_run")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	vs := mux.Vars(r)
+	dc := vs["dc"]
+	env := vs["env"]
+	app := vs["app"]
+	if !dryRun && isProtected(dc, env) {
+		return renderProtected(w, dc, env)
+	}
+
+	keys, err := getAllKeys(dc, env, app)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	changed := make(map[string]int64, len(keys))
+	unchanged := make([]string, 0, len(keys))
+	skipped := make([]string, 0, len(keys))
+	for _, key := range keys {
+		versions, err := getVersions(dc, env, app, key, t)
+		if err != nil {
+			return renderError(w, err)
+		} else if len(versions) == 0 {
+			skipped = append(skipped, key)
+			continue
+		}
+
+		target := versions[len(versions)-1]
+		v, _, err := backend.AppGetConfig(dc, env, app, key, target)
+		if err != nil {
+			return renderError(w, err)
+		}
+		latest, version, err := backend.AppGetConfig(dc, env, app, key, 0)
+		if err != nil {
+			return renderError(w, err)
+		}
+		if version == target || latest == v {
+			unchanged = append(unchanged, key)
+			continue
+		}
+
+		changed[key] = target
+		if !dryRun {
+			if _, err = rollbackKey(dc, env, app, key, target); err != nil {
+				return renderError(w, err)
+			}
+		}
+	}
+
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{
+		"dry_run":   dryRun,
+		"changed":   changed,
+		"unchanged": unchanged,
+		"skipped":   skipped,
+	})
+}
+
+// GetRollbacks returns the records of the rollback of the key.
+func GetRollbacks(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	rs, err := backend.GetRollbacks(vs["dc"], vs["env"], vs["app"], vs["key"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if rs == nil {
+		rs = []store.Rollback{}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"rollbacks": rs})
+}
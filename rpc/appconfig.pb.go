@@ -0,0 +1,1718 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: appconfig.proto
+
+// The gRPC service of the configuration manager, which mirrors the REST API.
+//
+// Generate the code of the package rpc by
+//
+//     protoc --go_out=. --go_opt=paths=source_relative \
+//         --go-grpc_out=. --go-grpc_opt=paths=source_relative appconfig.proto
+//
+// The clients in other languages can be generated from this file, too.
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Empty is the empty message.
+type Empty struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Empty) Reset() {
+	*x = Empty{}
+	mi := &file_appconfig_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Empty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Empty) ProtoMessage() {}
+
+func (x *Empty) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Empty.ProtoReflect.Descriptor instead.
+func (*Empty) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{0}
+}
+
+// Key is the key of app in dc and env.
+type Key struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dc    string                 `protobuf:"bytes,1,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env   string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	App   string                 `protobuf:"bytes,3,opt,name=app,proto3" json:"app,omitempty"`
+	Key   string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	// time is the version of the value. If it's 0, it's the latest value
+	// or the whole key.
+	Time int64 `protobuf:"varint,5,opt,name=time,proto3" json:"time,omitempty"`
+	// purge is only used by DeleteConfig. If true, delete the config
+	// permanently, or move it into the trash.
+	Purge bool `protobuf:"varint,6,opt,name=purge,proto3" json:"purge,omitempty"`
+	// raw is only used by GetConfig and GetAppConfig. If true, the references
+	// in the values are not resolved.
+	Raw bool `protobuf:"varint,7,opt,name=raw,proto3" json:"raw,omitempty"`
+	// at and tag are only used by GetConfig and GetAppConfig, which return
+	// the values at the unixstamp time or pinned by the tag.
+	At            int64  `protobuf:"varint,8,opt,name=at,proto3" json:"at,omitempty"`
+	Tag           string `protobuf:"bytes,9,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Key) Reset() {
+	*x = Key{}
+	mi := &file_appconfig_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Key) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Key) ProtoMessage() {}
+
+func (x *Key) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Key.ProtoReflect.Descriptor instead.
+func (*Key) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Key) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *Key) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *Key) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *Key) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Key) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Key) GetPurge() bool {
+	if x != nil {
+		return x.Purge
+	}
+	return false
+}
+
+func (x *Key) GetRaw() bool {
+	if x != nil {
+		return x.Raw
+	}
+	return false
+}
+
+func (x *Key) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+func (x *Key) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+// KeyValue is the value of the key of app in dc and env.
+type KeyValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Dc    string                 `protobuf:"bytes,1,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env   string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	App   string                 `protobuf:"bytes,3,opt,name=app,proto3" json:"app,omitempty"`
+	Key   string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Value string                 `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	// If draft is true, save the value as the draft. If at is greater than 0,
+	// schedule the value to be set at the unixstamp time.
+	Draft         bool  `protobuf:"varint,6,opt,name=draft,proto3" json:"draft,omitempty"`
+	At            int64 `protobuf:"varint,7,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeyValue) Reset() {
+	*x = KeyValue{}
+	mi := &file_appconfig_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyValue) ProtoMessage() {}
+
+func (x *KeyValue) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyValue.ProtoReflect.Descriptor instead.
+func (*KeyValue) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KeyValue) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *KeyValue) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *KeyValue) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *KeyValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KeyValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *KeyValue) GetDraft() bool {
+	if x != nil {
+		return x.Draft
+	}
+	return false
+}
+
+func (x *KeyValue) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+// UploadResult is the result of SetKeyValue. If the value is scheduled,
+// schedule is set. If the env is protected, the value is proposed as change,
+// and diff is the unified diff of it, and stale is true if the key has been
+// changed since proposed.
+type UploadResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schedule      *Schedule              `protobuf:"bytes,1,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	Change        *Change                `protobuf:"bytes,2,opt,name=change,proto3" json:"change,omitempty"`
+	Diff          string                 `protobuf:"bytes,3,opt,name=diff,proto3" json:"diff,omitempty"`
+	Stale         bool                   `protobuf:"varint,4,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadResult) Reset() {
+	*x = UploadResult{}
+	mi := &file_appconfig_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadResult) ProtoMessage() {}
+
+func (x *UploadResult) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadResult.ProtoReflect.Descriptor instead.
+func (*UploadResult) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UploadResult) GetSchedule() *Schedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+func (x *UploadResult) GetChange() *Change {
+	if x != nil {
+		return x.Change
+	}
+	return nil
+}
+
+func (x *UploadResult) GetDiff() string {
+	if x != nil {
+		return x.Diff
+	}
+	return ""
+}
+
+func (x *UploadResult) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+// Schedule is the value of a key, which is set at the unixstamp time at.
+type Schedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Dc            string                 `protobuf:"bytes,2,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,3,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,4,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	At            int64                  `protobuf:"varint,7,opt,name=at,proto3" json:"at,omitempty"`
+	Time          int64                  `protobuf:"varint,8,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_appconfig_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Schedule) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Schedule) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *Schedule) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *Schedule) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *Schedule) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Schedule) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Schedule) GetAt() int64 {
+	if x != nil {
+		return x.At
+	}
+	return 0
+}
+
+func (x *Schedule) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+// Change is the change of the value of a key in the protected env, which is
+// set only when approved.
+type Change struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Dc            string                 `protobuf:"bytes,2,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,3,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,4,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,6,opt,name=value,proto3" json:"value,omitempty"`
+	Base          int64                  `protobuf:"varint,7,opt,name=base,proto3" json:"base,omitempty"`
+	Requester     string                 `protobuf:"bytes,8,opt,name=requester,proto3" json:"requester,omitempty"`
+	Time          int64                  `protobuf:"varint,9,opt,name=time,proto3" json:"time,omitempty"`
+	Status        string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	Approvers     []string               `protobuf:"bytes,11,rep,name=approvers,proto3" json:"approvers,omitempty"`
+	Reviewer      string                 `protobuf:"bytes,12,opt,name=reviewer,proto3" json:"reviewer,omitempty"`
+	Reason        string                 `protobuf:"bytes,13,opt,name=reason,proto3" json:"reason,omitempty"`
+	Version       int64                  `protobuf:"varint,14,opt,name=version,proto3" json:"version,omitempty"`
+	Revision      int64                  `protobuf:"varint,15,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Change) Reset() {
+	*x = Change{}
+	mi := &file_appconfig_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Change) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Change) ProtoMessage() {}
+
+func (x *Change) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Change.ProtoReflect.Descriptor instead.
+func (*Change) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Change) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Change) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *Change) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *Change) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *Change) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Change) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Change) GetBase() int64 {
+	if x != nil {
+		return x.Base
+	}
+	return 0
+}
+
+func (x *Change) GetRequester() string {
+	if x != nil {
+		return x.Requester
+	}
+	return ""
+}
+
+func (x *Change) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Change) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Change) GetApprovers() []string {
+	if x != nil {
+		return x.Approvers
+	}
+	return nil
+}
+
+func (x *Change) GetReviewer() string {
+	if x != nil {
+		return x.Reviewer
+	}
+	return ""
+}
+
+func (x *Change) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *Change) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Change) GetRevision() int64 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+// Value is the value of a key, and version is the time when it was set.
+//
+// For GetConfig, level is the level of the fallback chain serving the value,
+// and namespace is the namespace serving it, or "" if served by the app.
+type Value struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         string                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Level         string                 `protobuf:"bytes,3,opt,name=level,proto3" json:"level,omitempty"`
+	Namespace     string                 `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Value) Reset() {
+	*x = Value{}
+	mi := &file_appconfig_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Value) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Value) ProtoMessage() {}
+
+func (x *Value) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Value.ProtoReflect.Descriptor instead.
+func (*Value) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Value) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Value) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Value) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *Value) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+// Config is the values of all the keys of an app, and version is the latest
+// version of them.
+type Config struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        map[string]string      `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Config) Reset() {
+	*x = Config{}
+	mi := &file_appconfig_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Config) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Config) ProtoMessage() {}
+
+func (x *Config) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Config.ProtoReflect.Descriptor instead.
+func (*Config) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Config) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Config) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// EnvList is all the envs in a dc.
+type EnvList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Envs          []string               `protobuf:"bytes,1,rep,name=envs,proto3" json:"envs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnvList) Reset() {
+	*x = EnvList{}
+	mi := &file_appconfig_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnvList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnvList) ProtoMessage() {}
+
+func (x *EnvList) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnvList.ProtoReflect.Descriptor instead.
+func (*EnvList) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EnvList) GetEnvs() []string {
+	if x != nil {
+		return x.Envs
+	}
+	return nil
+}
+
+// DcAndEnvs is all the dcs and envs. The key of envs is dc.
+type DcAndEnvs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Envs          map[string]*EnvList    `protobuf:"bytes,1,rep,name=envs,proto3" json:"envs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DcAndEnvs) Reset() {
+	*x = DcAndEnvs{}
+	mi := &file_appconfig_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DcAndEnvs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DcAndEnvs) ProtoMessage() {}
+
+func (x *DcAndEnvs) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DcAndEnvs.ProtoReflect.Descriptor instead.
+func (*DcAndEnvs) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DcAndEnvs) GetEnvs() map[string]*EnvList {
+	if x != nil {
+		return x.Envs
+	}
+	return nil
+}
+
+// ListRequest is the request to list the apps, keys or values.
+//
+// page is the ith page, which is 1 by default, and size is the number of
+// the items in one page, which is 20 by default. search is used to filter
+// the apps or keys by the name, and from and to are used to filter the values
+// by the version.
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dc            string                 `protobuf:"bytes,1,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,3,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Search        string                 `protobuf:"bytes,5,opt,name=search,proto3" json:"search,omitempty"`
+	Page          int64                  `protobuf:"varint,6,opt,name=page,proto3" json:"page,omitempty"`
+	Size          int64                  `protobuf:"varint,7,opt,name=size,proto3" json:"size,omitempty"`
+	From          int64                  `protobuf:"varint,8,opt,name=from,proto3" json:"from,omitempty"`
+	To            int64                  `protobuf:"varint,9,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_appconfig_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListRequest) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *ListRequest) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *ListRequest) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *ListRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ListRequest) GetSearch() string {
+	if x != nil {
+		return x.Search
+	}
+	return ""
+}
+
+func (x *ListRequest) GetPage() int64 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListRequest) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *ListRequest) GetFrom() int64 {
+	if x != nil {
+		return x.From
+	}
+	return 0
+}
+
+func (x *ListRequest) GetTo() int64 {
+	if x != nil {
+		return x.To
+	}
+	return 0
+}
+
+// NameList is a page of the names of the apps or keys, and total is the total
+// number of them.
+type NameList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Names         []string               `protobuf:"bytes,2,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NameList) Reset() {
+	*x = NameList{}
+	mi := &file_appconfig_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NameList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NameList) ProtoMessage() {}
+
+func (x *NameList) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NameList.ProtoReflect.Descriptor instead.
+func (*NameList) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *NameList) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *NameList) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+// ValueList is a page of the values of a key in the ascending order of
+// the version, and total is the total number of them.
+type ValueList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int64                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Values        []*Value               `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueList) Reset() {
+	*x = ValueList{}
+	mi := &file_appconfig_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueList) ProtoMessage() {}
+
+func (x *ValueList) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueList.ProtoReflect.Descriptor instead.
+func (*ValueList) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ValueList) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ValueList) GetValues() []*Value {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+// Callback is the callback notification of a key, which is identified by id.
+type Callback struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dc            string                 `protobuf:"bytes,1,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,3,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	Id            string                 `protobuf:"bytes,5,opt,name=id,proto3" json:"id,omitempty"`
+	Callback      string                 `protobuf:"bytes,6,opt,name=callback,proto3" json:"callback,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Callback) Reset() {
+	*x = Callback{}
+	mi := &file_appconfig_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Callback) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Callback) ProtoMessage() {}
+
+func (x *Callback) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Callback.ProtoReflect.Descriptor instead.
+func (*Callback) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Callback) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *Callback) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *Callback) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *Callback) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Callback) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Callback) GetCallback() string {
+	if x != nil {
+		return x.Callback
+	}
+	return ""
+}
+
+// CallbackList is all the callback notifications of a key. The key of
+// callbacks is the id, and the value is the callback.
+type CallbackList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Callbacks     map[string]string      `protobuf:"bytes,1,rep,name=callbacks,proto3" json:"callbacks,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallbackList) Reset() {
+	*x = CallbackList{}
+	mi := &file_appconfig_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallbackList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallbackList) ProtoMessage() {}
+
+func (x *CallbackList) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallbackList.ProtoReflect.Descriptor instead.
+func (*CallbackList) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CallbackList) GetCallbacks() map[string]string {
+	if x != nil {
+		return x.Callbacks
+	}
+	return nil
+}
+
+// CallbackResult is the result of a callback notification.
+//
+// If the callback is successful, result is "". Or it is the error string.
+type CallbackResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Time          int64                  `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Callback      string                 `protobuf:"bytes,2,opt,name=callback,proto3" json:"callback,omitempty"`
+	Result        string                 `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallbackResult) Reset() {
+	*x = CallbackResult{}
+	mi := &file_appconfig_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallbackResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallbackResult) ProtoMessage() {}
+
+func (x *CallbackResult) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallbackResult.ProtoReflect.Descriptor instead.
+func (*CallbackResult) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CallbackResult) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *CallbackResult) GetCallback() string {
+	if x != nil {
+		return x.Callback
+	}
+	return ""
+}
+
+func (x *CallbackResult) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+// CallbackResultList is the recent results of a callback notification.
+type CallbackResultList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*CallbackResult      `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CallbackResultList) Reset() {
+	*x = CallbackResultList{}
+	mi := &file_appconfig_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CallbackResultList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CallbackResultList) ProtoMessage() {}
+
+func (x *CallbackResultList) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CallbackResultList.ProtoReflect.Descriptor instead.
+func (*CallbackResultList) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CallbackResultList) GetResults() []*CallbackResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// WatchRequest is the request to watch the change events under dc, env, app
+// and key. If app or key is "", watch all the events under the parent.
+//
+// If last_event_id is greater than 0, the events after it will be sent firstly.
+type WatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dc            string                 `protobuf:"bytes,1,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,2,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,3,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,4,opt,name=key,proto3" json:"key,omitempty"`
+	LastEventId   int64                  `protobuf:"varint,5,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_appconfig_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *WatchRequest) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+// Event is the change event of the config.
+//
+// For deleting the whole dc, env or app, the rest are "". time is the version
+// of the value to be set or deleted, or 0 if deleting the whole key, dc, env
+// or app. deleted is true if the config is deleted, or false if set.
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Dc            string                 `protobuf:"bytes,2,opt,name=dc,proto3" json:"dc,omitempty"`
+	Env           string                 `protobuf:"bytes,3,opt,name=env,proto3" json:"env,omitempty"`
+	App           string                 `protobuf:"bytes,4,opt,name=app,proto3" json:"app,omitempty"`
+	Key           string                 `protobuf:"bytes,5,opt,name=key,proto3" json:"key,omitempty"`
+	Time          int64                  `protobuf:"varint,6,opt,name=time,proto3" json:"time,omitempty"`
+	Value         string                 `protobuf:"bytes,7,opt,name=value,proto3" json:"value,omitempty"`
+	Deleted       bool                   `protobuf:"varint,8,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_appconfig_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_appconfig_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_appconfig_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *Event) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Event) GetDc() string {
+	if x != nil {
+		return x.Dc
+	}
+	return ""
+}
+
+func (x *Event) GetEnv() string {
+	if x != nil {
+		return x.Env
+	}
+	return ""
+}
+
+func (x *Event) GetApp() string {
+	if x != nil {
+		return x.App
+	}
+	return ""
+}
+
+func (x *Event) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Event) GetTime() int64 {
+	if x != nil {
+		return x.Time
+	}
+	return 0
+}
+
+func (x *Event) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *Event) GetDeleted() bool {
+	if x != nil {
+		return x.Deleted
+	}
+	return false
+}
+
+var File_appconfig_proto protoreflect.FileDescriptor
+
+const file_appconfig_proto_rawDesc = "" +
+	"\n" +
+	"\x0fappconfig.proto\x12\tappconfig\"\a\n" +
+	"\x05Empty\"\xa9\x01\n" +
+	"\x03Key\x12\x0e\n" +
+	"\x02dc\x18\x01 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x03 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12\x12\n" +
+	"\x04time\x18\x05 \x01(\x03R\x04time\x12\x14\n" +
+	"\x05purge\x18\x06 \x01(\bR\x05purge\x12\x10\n" +
+	"\x03raw\x18\a \x01(\bR\x03raw\x12\x0e\n" +
+	"\x02at\x18\b \x01(\x03R\x02at\x12\x10\n" +
+	"\x03tag\x18\t \x01(\tR\x03tag\"\x8c\x01\n" +
+	"\bKeyValue\x12\x0e\n" +
+	"\x02dc\x18\x01 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x03 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x05 \x01(\tR\x05value\x12\x14\n" +
+	"\x05draft\x18\x06 \x01(\bR\x05draft\x12\x0e\n" +
+	"\x02at\x18\a \x01(\x03R\x02at\"\x94\x01\n" +
+	"\fUploadResult\x12/\n" +
+	"\bschedule\x18\x01 \x01(\v2\x13.appconfig.ScheduleR\bschedule\x12)\n" +
+	"\x06change\x18\x02 \x01(\v2\x11.appconfig.ChangeR\x06change\x12\x12\n" +
+	"\x04diff\x18\x03 \x01(\tR\x04diff\x12\x14\n" +
+	"\x05stale\x18\x04 \x01(\bR\x05stale\"\x9a\x01\n" +
+	"\bSchedule\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x0e\n" +
+	"\x02dc\x18\x02 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x03 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x04 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x05 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\tR\x05value\x12\x0e\n" +
+	"\x02at\x18\a \x01(\x03R\x02at\x12\x12\n" +
+	"\x04time\x18\b \x01(\x03R\x04time\"\xda\x02\n" +
+	"\x06Change\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x0e\n" +
+	"\x02dc\x18\x02 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x03 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x04 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x05 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x06 \x01(\tR\x05value\x12\x12\n" +
+	"\x04base\x18\a \x01(\x03R\x04base\x12\x1c\n" +
+	"\trequester\x18\b \x01(\tR\trequester\x12\x12\n" +
+	"\x04time\x18\t \x01(\x03R\x04time\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x12\x1c\n" +
+	"\tapprovers\x18\v \x03(\tR\tapprovers\x12\x1a\n" +
+	"\breviewer\x18\f \x01(\tR\breviewer\x12\x16\n" +
+	"\x06reason\x18\r \x01(\tR\x06reason\x12\x18\n" +
+	"\aversion\x18\x0e \x01(\x03R\aversion\x12\x1a\n" +
+	"\brevision\x18\x0f \x01(\x03R\brevision\"k\n" +
+	"\x05Value\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\tR\x05value\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x14\n" +
+	"\x05level\x18\x03 \x01(\tR\x05level\x12\x1c\n" +
+	"\tnamespace\x18\x04 \x01(\tR\tnamespace\"\x94\x01\n" +
+	"\x06Config\x125\n" +
+	"\x06values\x18\x01 \x03(\v2\x1d.appconfig.Config.ValuesEntryR\x06values\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x1d\n" +
+	"\aEnvList\x12\x12\n" +
+	"\x04envs\x18\x01 \x03(\tR\x04envs\"\x8c\x01\n" +
+	"\tDcAndEnvs\x122\n" +
+	"\x04envs\x18\x01 \x03(\v2\x1e.appconfig.DcAndEnvs.EnvsEntryR\x04envs\x1aK\n" +
+	"\tEnvsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12(\n" +
+	"\x05value\x18\x02 \x01(\v2\x12.appconfig.EnvListR\x05value:\x028\x01\"\xb7\x01\n" +
+	"\vListRequest\x12\x0e\n" +
+	"\x02dc\x18\x01 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x03 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12\x16\n" +
+	"\x06search\x18\x05 \x01(\tR\x06search\x12\x12\n" +
+	"\x04page\x18\x06 \x01(\x03R\x04page\x12\x12\n" +
+	"\x04size\x18\a \x01(\x03R\x04size\x12\x12\n" +
+	"\x04from\x18\b \x01(\x03R\x04from\x12\x0e\n" +
+	"\x02to\x18\t \x01(\x03R\x02to\"6\n" +
+	"\bNameList\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12\x14\n" +
+	"\x05names\x18\x02 \x03(\tR\x05names\"K\n" +
+	"\tValueList\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x03R\x05total\x12(\n" +
+	"\x06values\x18\x02 \x03(\v2\x10.appconfig.ValueR\x06values\"|\n" +
+	"\bCallback\x12\x0e\n" +
+	"\x02dc\x18\x01 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x03 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12\x0e\n" +
+	"\x02id\x18\x05 \x01(\tR\x02id\x12\x1a\n" +
+	"\bcallback\x18\x06 \x01(\tR\bcallback\"\x92\x01\n" +
+	"\fCallbackList\x12D\n" +
+	"\tcallbacks\x18\x01 \x03(\v2&.appconfig.CallbackList.CallbacksEntryR\tcallbacks\x1a<\n" +
+	"\x0eCallbacksEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"X\n" +
+	"\x0eCallbackResult\x12\x12\n" +
+	"\x04time\x18\x01 \x01(\x03R\x04time\x12\x1a\n" +
+	"\bcallback\x18\x02 \x01(\tR\bcallback\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\tR\x06result\"I\n" +
+	"\x12CallbackResultList\x123\n" +
+	"\aresults\x18\x01 \x03(\v2\x19.appconfig.CallbackResultR\aresults\"x\n" +
+	"\fWatchRequest\x12\x0e\n" +
+	"\x02dc\x18\x01 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x02 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x03 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x04 \x01(\tR\x03key\x12\"\n" +
+	"\rlast_event_id\x18\x05 \x01(\x03R\vlastEventId\"\xa1\x01\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x0e\n" +
+	"\x02dc\x18\x02 \x01(\tR\x02dc\x12\x10\n" +
+	"\x03env\x18\x03 \x01(\tR\x03env\x12\x10\n" +
+	"\x03app\x18\x04 \x01(\tR\x03app\x12\x10\n" +
+	"\x03key\x18\x05 \x01(\tR\x03key\x12\x12\n" +
+	"\x04time\x18\x06 \x01(\x03R\x04time\x12\x14\n" +
+	"\x05value\x18\a \x01(\tR\x05value\x12\x18\n" +
+	"\adeleted\x18\b \x01(\bR\adeleted2\xa5\x06\n" +
+	"\tAppConfig\x12-\n" +
+	"\tGetConfig\x12\x0e.appconfig.Key\x1a\x10.appconfig.Value\x121\n" +
+	"\fGetAppConfig\x12\x0e.appconfig.Key\x1a\x11.appconfig.Config\x122\n" +
+	"\x0eCreateDcAndEnv\x12\x0e.appconfig.Key\x1a\x10.appconfig.Empty\x129\n" +
+	"\x0fGetAllDcAndEnvs\x12\x10.appconfig.Empty\x1a\x14.appconfig.DcAndEnvs\x12;\n" +
+	"\vSetKeyValue\x12\x13.appconfig.KeyValue\x1a\x17.appconfig.UploadResult\x129\n" +
+	"\n" +
+	"GetAllApps\x12\x16.appconfig.ListRequest\x1a\x13.appconfig.NameList\x129\n" +
+	"\n" +
+	"GetAllKeys\x12\x16.appconfig.ListRequest\x1a\x13.appconfig.NameList\x12<\n" +
+	"\fGetAllValues\x12\x16.appconfig.ListRequest\x1a\x14.appconfig.ValueList\x120\n" +
+	"\fDeleteConfig\x12\x0e.appconfig.Key\x1a\x10.appconfig.Empty\x126\n" +
+	"\vGetCallback\x12\x0e.appconfig.Key\x1a\x17.appconfig.CallbackList\x124\n" +
+	"\vAddCallback\x12\x13.appconfig.Callback\x1a\x10.appconfig.Empty\x127\n" +
+	"\x0eDeleteCallback\x12\x13.appconfig.Callback\x1a\x10.appconfig.Empty\x12G\n" +
+	"\x11GetCallbackResult\x12\x13.appconfig.Callback\x1a\x1d.appconfig.CallbackResultList\x124\n" +
+	"\x05Watch\x12\x17.appconfig.WatchRequest\x1a\x10.appconfig.Event0\x01B!Z\x1fgithub.com/xgfone/appconfig/rpcb\x06proto3"
+
+var (
+	file_appconfig_proto_rawDescOnce sync.Once
+	file_appconfig_proto_rawDescData []byte
+)
+
+func file_appconfig_proto_rawDescGZIP() []byte {
+	file_appconfig_proto_rawDescOnce.Do(func() {
+		file_appconfig_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_appconfig_proto_rawDesc), len(file_appconfig_proto_rawDesc)))
+	})
+	return file_appconfig_proto_rawDescData
+}
+
+var file_appconfig_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_appconfig_proto_goTypes = []any{
+	(*Empty)(nil),              // 0: appconfig.Empty
+	(*Key)(nil),                // 1: appconfig.Key
+	(*KeyValue)(nil),           // 2: appconfig.KeyValue
+	(*UploadResult)(nil),       // 3: appconfig.UploadResult
+	(*Schedule)(nil),           // 4: appconfig.Schedule
+	(*Change)(nil),             // 5: appconfig.Change
+	(*Value)(nil),              // 6: appconfig.Value
+	(*Config)(nil),             // 7: appconfig.Config
+	(*EnvList)(nil),            // 8: appconfig.EnvList
+	(*DcAndEnvs)(nil),          // 9: appconfig.DcAndEnvs
+	(*ListRequest)(nil),        // 10: appconfig.ListRequest
+	(*NameList)(nil),           // 11: appconfig.NameList
+	(*ValueList)(nil),          // 12: appconfig.ValueList
+	(*Callback)(nil),           // 13: appconfig.Callback
+	(*CallbackList)(nil),       // 14: appconfig.CallbackList
+	(*CallbackResult)(nil),     // 15: appconfig.CallbackResult
+	(*CallbackResultList)(nil), // 16: appconfig.CallbackResultList
+	(*WatchRequest)(nil),       // 17: appconfig.WatchRequest
+	(*Event)(nil),              // 18: appconfig.Event
+	nil,                        // 19: appconfig.Config.ValuesEntry
+	nil,                        // 20: appconfig.DcAndEnvs.EnvsEntry
+	nil,                        // 21: appconfig.CallbackList.CallbacksEntry
+}
+var file_appconfig_proto_depIdxs = []int32{
+	4,  // 0: appconfig.UploadResult.schedule:type_name -> appconfig.Schedule
+	5,  // 1: appconfig.UploadResult.change:type_name -> appconfig.Change
+	19, // 2: appconfig.Config.values:type_name -> appconfig.Config.ValuesEntry
+	20, // 3: appconfig.DcAndEnvs.envs:type_name -> appconfig.DcAndEnvs.EnvsEntry
+	6,  // 4: appconfig.ValueList.values:type_name -> appconfig.Value
+	21, // 5: appconfig.CallbackList.callbacks:type_name -> appconfig.CallbackList.CallbacksEntry
+	15, // 6: appconfig.CallbackResultList.results:type_name -> appconfig.CallbackResult
+	8,  // 7: appconfig.DcAndEnvs.EnvsEntry.value:type_name -> appconfig.EnvList
+	1,  // 8: appconfig.AppConfig.GetConfig:input_type -> appconfig.Key
+	1,  // 9: appconfig.AppConfig.GetAppConfig:input_type -> appconfig.Key
+	1,  // 10: appconfig.AppConfig.CreateDcAndEnv:input_type -> appconfig.Key
+	0,  // 11: appconfig.AppConfig.GetAllDcAndEnvs:input_type -> appconfig.Empty
+	2,  // 12: appconfig.AppConfig.SetKeyValue:input_type -> appconfig.KeyValue
+	10, // 13: appconfig.AppConfig.GetAllApps:input_type -> appconfig.ListRequest
+	10, // 14: appconfig.AppConfig.GetAllKeys:input_type -> appconfig.ListRequest
+	10, // 15: appconfig.AppConfig.GetAllValues:input_type -> appconfig.ListRequest
+	1,  // 16: appconfig.AppConfig.DeleteConfig:input_type -> appconfig.Key
+	1,  // 17: appconfig.AppConfig.GetCallback:input_type -> appconfig.Key
+	13, // 18: appconfig.AppConfig.AddCallback:input_type -> appconfig.Callback
+	13, // 19: appconfig.AppConfig.DeleteCallback:input_type -> appconfig.Callback
+	13, // 20: appconfig.AppConfig.GetCallbackResult:input_type -> appconfig.Callback
+	17, // 21: appconfig.AppConfig.Watch:input_type -> appconfig.WatchRequest
+	6,  // 22: appconfig.AppConfig.GetConfig:output_type -> appconfig.Value
+	7,  // 23: appconfig.AppConfig.GetAppConfig:output_type -> appconfig.Config
+	0,  // 24: appconfig.AppConfig.CreateDcAndEnv:output_type -> appconfig.Empty
+	9,  // 25: appconfig.AppConfig.GetAllDcAndEnvs:output_type -> appconfig.DcAndEnvs
+	3,  // 26: appconfig.AppConfig.SetKeyValue:output_type -> appconfig.UploadResult
+	11, // 27: appconfig.AppConfig.GetAllApps:output_type -> appconfig.NameList
+	11, // 28: appconfig.AppConfig.GetAllKeys:output_type -> appconfig.NameList
+	12, // 29: appconfig.AppConfig.GetAllValues:output_type -> appconfig.ValueList
+	0,  // 30: appconfig.AppConfig.DeleteConfig:output_type -> appconfig.Empty
+	14, // 31: appconfig.AppConfig.GetCallback:output_type -> appconfig.CallbackList
+	0,  // 32: appconfig.AppConfig.AddCallback:output_type -> appconfig.Empty
+	0,  // 33: appconfig.AppConfig.DeleteCallback:output_type -> appconfig.Empty
+	16, // 34: appconfig.AppConfig.GetCallbackResult:output_type -> appconfig.CallbackResultList
+	18, // 35: appconfig.AppConfig.Watch:output_type -> appconfig.Event
+	22, // [22:36] is the sub-list for method output_type
+	8,  // [8:22] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_appconfig_proto_init() }
+func file_appconfig_proto_init() {
+	if File_appconfig_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_appconfig_proto_rawDesc), len(file_appconfig_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_appconfig_proto_goTypes,
+		DependencyIndexes: file_appconfig_proto_depIdxs,
+		MessageInfos:      file_appconfig_proto_msgTypes,
+	}.Build()
+	File_appconfig_proto = out.File
+	file_appconfig_proto_goTypes = nil
+	file_appconfig_proto_depIdxs = nil
+}
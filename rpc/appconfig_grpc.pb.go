@@ -0,0 +1,682 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: appconfig.proto
+
+// The gRPC service of the configuration manager, which mirrors the REST API.
+//
+// Generate the code of the package rpc by
+//
+//     protoc --go_out=. --go_opt=paths=source_relative \
+//         --go-grpc_out=. --go-grpc_opt=paths=source_relative appconfig.proto
+//
+// The clients in other languages can be generated from this file, too.
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AppConfig_GetConfig_FullMethodName         = "/appconfig.AppConfig/GetConfig"
+	AppConfig_GetAppConfig_FullMethodName      = "/appconfig.AppConfig/GetAppConfig"
+	AppConfig_CreateDcAndEnv_FullMethodName    = "/appconfig.AppConfig/CreateDcAndEnv"
+	AppConfig_GetAllDcAndEnvs_FullMethodName   = "/appconfig.AppConfig/GetAllDcAndEnvs"
+	AppConfig_SetKeyValue_FullMethodName       = "/appconfig.AppConfig/SetKeyValue"
+	AppConfig_GetAllApps_FullMethodName        = "/appconfig.AppConfig/GetAllApps"
+	AppConfig_GetAllKeys_FullMethodName        = "/appconfig.AppConfig/GetAllKeys"
+	AppConfig_GetAllValues_FullMethodName      = "/appconfig.AppConfig/GetAllValues"
+	AppConfig_DeleteConfig_FullMethodName      = "/appconfig.AppConfig/DeleteConfig"
+	AppConfig_GetCallback_FullMethodName       = "/appconfig.AppConfig/GetCallback"
+	AppConfig_AddCallback_FullMethodName       = "/appconfig.AppConfig/AddCallback"
+	AppConfig_DeleteCallback_FullMethodName    = "/appconfig.AppConfig/DeleteCallback"
+	AppConfig_GetCallbackResult_FullMethodName = "/appconfig.AppConfig/GetCallbackResult"
+	AppConfig_Watch_FullMethodName             = "/appconfig.AppConfig/Watch"
+)
+
+// AppConfigClient is the client API for AppConfig service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AppConfig is the gRPC service, which shares the same backend store and
+// callback notification with the REST API.
+//
+// The metadata "x-appconfig-user" is the identity of the user, who proposes
+// the changes to the protected envs, and "x-appconfig-instance" is the instance
+// ID of the app, which is matched by the canaries, like the request headers
+// of the REST API.
+type AppConfigClient interface {
+	// GetConfig returns the value of the key like the API 1 of the REST API.
+	GetConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error)
+	// GetAppConfig returns the values of all the keys of the app like the API 16
+	// of the REST API.
+	GetAppConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Config, error)
+	// CreateDcAndEnv creates the new dc and env.
+	CreateDcAndEnv(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Empty, error)
+	// GetAllDcAndEnvs returns all the dcs and envs.
+	GetAllDcAndEnvs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DcAndEnvs, error)
+	// SetKeyValue uploads the value of the key like the API 4 of the REST API,
+	// and notifies the callbacks.
+	SetKeyValue(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*UploadResult, error)
+	// GetAllApps returns the names of the apps in dc and env.
+	GetAllApps(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*NameList, error)
+	// GetAllKeys returns the names of the keys of the app.
+	GetAllKeys(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*NameList, error)
+	// GetAllValues returns the values of the key.
+	GetAllValues(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ValueList, error)
+	// DeleteConfig moves the whole dc, env, app or key into the trash, or
+	// deletes it permanently if purge is true. A value of the key is always
+	// deleted.
+	DeleteConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Empty, error)
+	// GetCallback returns all the callback notifications of the key.
+	GetCallback(ctx context.Context, in *Key, opts ...grpc.CallOption) (*CallbackList, error)
+	// AddCallback adds a callback notification for the key.
+	AddCallback(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*Empty, error)
+	// DeleteCallback deletes all the callback notifications of the key,
+	// or only the one identified by id if it's not "".
+	DeleteCallback(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*Empty, error)
+	// GetCallbackResult returns the recent results of the callback notification.
+	GetCallbackResult(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*CallbackResultList, error)
+	// Watch sends the change events until the client cancels it.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type appConfigClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAppConfigClient(cc grpc.ClientConnInterface) AppConfigClient {
+	return &appConfigClient{cc}
+}
+
+func (c *appConfigClient) GetConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Value, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Value)
+	err := c.cc.Invoke(ctx, AppConfig_GetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetAppConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Config, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Config)
+	err := c.cc.Invoke(ctx, AppConfig_GetAppConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) CreateDcAndEnv(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, AppConfig_CreateDcAndEnv_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetAllDcAndEnvs(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*DcAndEnvs, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DcAndEnvs)
+	err := c.cc.Invoke(ctx, AppConfig_GetAllDcAndEnvs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) SetKeyValue(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*UploadResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UploadResult)
+	err := c.cc.Invoke(ctx, AppConfig_SetKeyValue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetAllApps(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*NameList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NameList)
+	err := c.cc.Invoke(ctx, AppConfig_GetAllApps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetAllKeys(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*NameList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(NameList)
+	err := c.cc.Invoke(ctx, AppConfig_GetAllKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetAllValues(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ValueList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValueList)
+	err := c.cc.Invoke(ctx, AppConfig_GetAllValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) DeleteConfig(ctx context.Context, in *Key, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, AppConfig_DeleteConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetCallback(ctx context.Context, in *Key, opts ...grpc.CallOption) (*CallbackList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CallbackList)
+	err := c.cc.Invoke(ctx, AppConfig_GetCallback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) AddCallback(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, AppConfig_AddCallback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) DeleteCallback(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, AppConfig_DeleteCallback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) GetCallbackResult(ctx context.Context, in *Callback, opts ...grpc.CallOption) (*CallbackResultList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CallbackResultList)
+	err := c.cc.Invoke(ctx, AppConfig_GetCallbackResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *appConfigClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AppConfig_ServiceDesc.Streams[0], AppConfig_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AppConfig_WatchClient = grpc.ServerStreamingClient[Event]
+
+// AppConfigServer is the server API for AppConfig service.
+// All implementations must embed UnimplementedAppConfigServer
+// for forward compatibility.
+//
+// AppConfig is the gRPC service, which shares the same backend store and
+// callback notification with the REST API.
+//
+// The metadata "x-appconfig-user" is the identity of the user, who proposes
+// the changes to the protected envs, and "x-appconfig-instance" is the instance
+// ID of the app, which is matched by the canaries, like the request headers
+// of the REST API.
+type AppConfigServer interface {
+	// GetConfig returns the value of the key like the API 1 of the REST API.
+	GetConfig(context.Context, *Key) (*Value, error)
+	// GetAppConfig returns the values of all the keys of the app like the API 16
+	// of the REST API.
+	GetAppConfig(context.Context, *Key) (*Config, error)
+	// CreateDcAndEnv creates the new dc and env.
+	CreateDcAndEnv(context.Context, *Key) (*Empty, error)
+	// GetAllDcAndEnvs returns all the dcs and envs.
+	GetAllDcAndEnvs(context.Context, *Empty) (*DcAndEnvs, error)
+	// SetKeyValue uploads the value of the key like the API 4 of the REST API,
+	// and notifies the callbacks.
+	SetKeyValue(context.Context, *KeyValue) (*UploadResult, error)
+	// GetAllApps returns the names of the apps in dc and env.
+	GetAllApps(context.Context, *ListRequest) (*NameList, error)
+	// GetAllKeys returns the names of the keys of the app.
+	GetAllKeys(context.Context, *ListRequest) (*NameList, error)
+	// GetAllValues returns the values of the key.
+	GetAllValues(context.Context, *ListRequest) (*ValueList, error)
+	// DeleteConfig moves the whole dc, env, app or key into the trash, or
+	// deletes it permanently if purge is true. A value of the key is always
+	// deleted.
+	DeleteConfig(context.Context, *Key) (*Empty, error)
+	// GetCallback returns all the callback notifications of the key.
+	GetCallback(context.Context, *Key) (*CallbackList, error)
+	// AddCallback adds a callback notification for the key.
+	AddCallback(context.Context, *Callback) (*Empty, error)
+	// DeleteCallback deletes all the callback notifications of the key,
+	// or only the one identified by id if it's not "".
+	DeleteCallback(context.Context, *Callback) (*Empty, error)
+	// GetCallbackResult returns the recent results of the callback notification.
+	GetCallbackResult(context.Context, *Callback) (*CallbackResultList, error)
+	// Watch sends the change events until the client cancels it.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedAppConfigServer()
+}
+
+// UnimplementedAppConfigServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAppConfigServer struct{}
+
+func (UnimplementedAppConfigServer) GetConfig(context.Context, *Key) (*Value, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedAppConfigServer) GetAppConfig(context.Context, *Key) (*Config, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAppConfig not implemented")
+}
+func (UnimplementedAppConfigServer) CreateDcAndEnv(context.Context, *Key) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateDcAndEnv not implemented")
+}
+func (UnimplementedAppConfigServer) GetAllDcAndEnvs(context.Context, *Empty) (*DcAndEnvs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllDcAndEnvs not implemented")
+}
+func (UnimplementedAppConfigServer) SetKeyValue(context.Context, *KeyValue) (*UploadResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetKeyValue not implemented")
+}
+func (UnimplementedAppConfigServer) GetAllApps(context.Context, *ListRequest) (*NameList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllApps not implemented")
+}
+func (UnimplementedAppConfigServer) GetAllKeys(context.Context, *ListRequest) (*NameList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllKeys not implemented")
+}
+func (UnimplementedAppConfigServer) GetAllValues(context.Context, *ListRequest) (*ValueList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAllValues not implemented")
+}
+func (UnimplementedAppConfigServer) DeleteConfig(context.Context, *Key) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteConfig not implemented")
+}
+func (UnimplementedAppConfigServer) GetCallback(context.Context, *Key) (*CallbackList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCallback not implemented")
+}
+func (UnimplementedAppConfigServer) AddCallback(context.Context, *Callback) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCallback not implemented")
+}
+func (UnimplementedAppConfigServer) DeleteCallback(context.Context, *Callback) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCallback not implemented")
+}
+func (UnimplementedAppConfigServer) GetCallbackResult(context.Context, *Callback) (*CallbackResultList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCallbackResult not implemented")
+}
+func (UnimplementedAppConfigServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedAppConfigServer) mustEmbedUnimplementedAppConfigServer() {}
+func (UnimplementedAppConfigServer) testEmbeddedByValue()                   {}
+
+// UnsafeAppConfigServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AppConfigServer will
+// result in compilation errors.
+type UnsafeAppConfigServer interface {
+	mustEmbedUnimplementedAppConfigServer()
+}
+
+func RegisterAppConfigServer(s grpc.ServiceRegistrar, srv AppConfigServer) {
+	// If the following call pancis, it indicates UnimplementedAppConfigServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AppConfig_ServiceDesc, srv)
+}
+
+func _AppConfig_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetConfig(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetAppConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetAppConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetAppConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetAppConfig(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_CreateDcAndEnv_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).CreateDcAndEnv(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_CreateDcAndEnv_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).CreateDcAndEnv(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetAllDcAndEnvs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetAllDcAndEnvs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetAllDcAndEnvs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetAllDcAndEnvs(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_SetKeyValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).SetKeyValue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_SetKeyValue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).SetKeyValue(ctx, req.(*KeyValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetAllApps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetAllApps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetAllApps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetAllApps(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetAllKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetAllKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetAllKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetAllKeys(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetAllValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetAllValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetAllValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetAllValues(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_DeleteConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).DeleteConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_DeleteConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).DeleteConfig(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetCallback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Key)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetCallback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetCallback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetCallback(ctx, req.(*Key))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_AddCallback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Callback)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).AddCallback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_AddCallback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).AddCallback(ctx, req.(*Callback))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_DeleteCallback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Callback)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).DeleteCallback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_DeleteCallback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).DeleteCallback(ctx, req.(*Callback))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_GetCallbackResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Callback)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AppConfigServer).GetCallbackResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AppConfig_GetCallbackResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AppConfigServer).GetCallbackResult(ctx, req.(*Callback))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AppConfig_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AppConfigServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AppConfig_WatchServer = grpc.ServerStreamingServer[Event]
+
+// AppConfig_ServiceDesc is the grpc.ServiceDesc for AppConfig service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AppConfig_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "appconfig.AppConfig",
+	HandlerType: (*AppConfigServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetConfig",
+			Handler:    _AppConfig_GetConfig_Handler,
+		},
+		{
+			MethodName: "GetAppConfig",
+			Handler:    _AppConfig_GetAppConfig_Handler,
+		},
+		{
+			MethodName: "CreateDcAndEnv",
+			Handler:    _AppConfig_CreateDcAndEnv_Handler,
+		},
+		{
+			MethodName: "GetAllDcAndEnvs",
+			Handler:    _AppConfig_GetAllDcAndEnvs_Handler,
+		},
+		{
+			MethodName: "SetKeyValue",
+			Handler:    _AppConfig_SetKeyValue_Handler,
+		},
+		{
+			MethodName: "GetAllApps",
+			Handler:    _AppConfig_GetAllApps_Handler,
+		},
+		{
+			MethodName: "GetAllKeys",
+			Handler:    _AppConfig_GetAllKeys_Handler,
+		},
+		{
+			MethodName: "GetAllValues",
+			Handler:    _AppConfig_GetAllValues_Handler,
+		},
+		{
+			MethodName: "DeleteConfig",
+			Handler:    _AppConfig_DeleteConfig_Handler,
+		},
+		{
+			MethodName: "GetCallback",
+			Handler:    _AppConfig_GetCallback_Handler,
+		},
+		{
+			MethodName: "AddCallback",
+			Handler:    _AppConfig_AddCallback_Handler,
+		},
+		{
+			MethodName: "DeleteCallback",
+			Handler:    _AppConfig_DeleteCallback_Handler,
+		},
+		{
+			MethodName: "GetCallbackResult",
+			Handler:    _AppConfig_GetCallbackResult_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _AppConfig_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "appconfig.proto",
+}
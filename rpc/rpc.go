@@ -0,0 +1,9 @@
+// Package rpc is the gRPC service of the configuration manager, which mirrors
+// the REST API.
+//
+// The service is defined by appconfig.proto, from which the clients in other
+// languages can be generated, and the code in this package is generated by
+// protoc-gen-go and protoc-gen-go-grpc.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative appconfig.proto
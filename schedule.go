@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// scheduleConfig schedules the value of the key, which is set at the time at.
+func scheduleConfig(dc, env, app, key, value string, at int64) (store.Schedule,
+	error) {
+	if at <= time.Now().Unix() {
+		return store.Schedule{}, badRequestError("at must be in the future")
+	} else if isProtected(dc, env) {
+		return store.Schedule{}, protectedError(dc, env)
+	}
+
+	s, err := backend.AddSchedule(store.Schedule{Dc: dc, Env: env, App: app,
+		Key: key, Value: value, At: at})
+	printLog(err, "Schedule dc=%s, env=%s, app=%s, key=%s at %d", dc, env, app,
+		key, at)
+	return s, err
+}
+
+// GetSchedules returns the schedules, which are filtered by the query
+// arguments dc, env and app.
+func GetSchedules(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+	schedules, err := backend.GetSchedules(http2.GetQuery(query, "dc"),
+		http2.GetQuery(query, "env"), http2.GetQuery(query, "app"))
+	if err != nil {
+		return renderError(w, err)
+	}
+	if schedules == nil {
+		schedules = []store.Schedule{}
+	}
+	for i, s := range schedules {
+		if schedules[i].Value, err = maskValue(s.Dc, s.Env, s.App, s.Key,
+			s.Value); err != nil {
+			return renderError(w, err)
+		}
+	}
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"schedules": schedules})
+}
+
+// CancelSchedule cancels the schedule, which has not been applied.
+func CancelSchedule(w http.ResponseWriter, r *http.Request) error {
+	id := mux.Vars(r)["id"]
+	_, err := backend.DeleteSchedule(id)
+	printLog(err, "Cancel the schedule id=%s", id)
+	return renderError(w, err)
+}
+
+// applySchedules sets the values of the due schedules every interval,
+// and notifies the callbacks. It never returns.
+//
+// Each schedule is applied by the backend store, which sets the value
+// and deletes the schedule atomically, so it's applied exactly once even if
+// many instances share the backend store. If failed, it's retried next time.
+func applySchedules(interval time.Duration) {
+	for {
+		schedules, err := backend.GetSchedules("", "", "")
+		if err != nil {
+			logger.Errorf("cannot get the schedules: %s", err)
+		}
+
+		now := time.Now().Unix()
+		for _, s := range schedules {
+			if s.At > now {
+				break
+			}
+
+			// Another instance may have applied it, or it has been cancelled.
+			if _, err = backend.ApplySchedule(s.ID); err == store.ErrNotFound {
+				continue
+			}
+			printLog(err, "Apply the schedule id=%s, dc=%s, env=%s, app=%s, key=%s",
+				s.ID, s.Dc, s.Env, s.App, s.Key)
+			if err == nil {
+				err = notifyCallbacks(s.Dc, s.Env, s.App, s.Key, s.Value)
+				printLog(err, "Notify the callbacks of the schedule id=%s", s.ID)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
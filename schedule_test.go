@@ -0,0 +1,46 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestApplySchedule(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	s, err := backend.AddSchedule(store.Schedule{Dc: "bj", Env: "dev", App: "a",
+		Key: "k1", Value: "v1", At: time.Now().Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only one of the concurrent appliers succeeds.
+	var applied int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := backend.ApplySchedule(s.ID); err == nil {
+				atomic.AddInt32(&applied, 1)
+			} else if err != store.ErrNotFound {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if applied != 1 {
+		t.Errorf("expected the schedule applied once, but %d", applied)
+	}
+	if v, _, _ := backend.AppGetConfig("bj", "dev", "a", "k1", 0); v != "v1" {
+		t.Errorf("expected the scheduled value 'v1', but got '%s'", v)
+	}
+	if ss, _ := backend.GetSchedules("bj", "dev", "a"); len(ss) != 0 {
+		t.Errorf("unexpected schedules %+v", ss)
+	}
+}
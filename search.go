@@ -0,0 +1,350 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// snippetContext is the number of the bytes around the match in the snippet.
+const snippetContext = 32
+
+// searchIndex is the global index to search the keys and the values.
+var searchIndex = newConfigIndex()
+
+// configPath is the location of a key.
+type configPath struct {
+	Dc  string
+	Env string
+	App string
+	Key string
+}
+
+// configIndex is the in-memory secondary index of the latest values of all
+// the keys, which is built from the backend store once, then kept up to date
+// by the change events, so searching needs not scan the backend store.
+type configIndex struct {
+	sync.RWMutex
+	ready  bool
+	values map[configPath]string
+}
+
+func newConfigIndex() *configIndex {
+	return &configIndex{values: make(map[configPath]string)}
+}
+
+// scan adds the latest values of the keys in dc and env, or only in app
+// if app is not "", into values.
+func (x *configIndex) scan(values map[configPath]string, dc, env, app string) error {
+	apps := []string{app}
+	if app == "" {
+		var err error
+		if apps, err = getAllApps(dc, env); err == store.ErrNotFound {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+
+	for _, app := range apps {
+		kvs, _, err := getAppConfig(dc, env, app)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		for key, v := range kvs {
+			values[configPath{Dc: dc, Env: env, App: app, Key: key}] = v
+		}
+	}
+	return nil
+}
+
+// build rebuilds the whole index from the backend store.
+func (x *configIndex) build() error {
+	dcs, err := backend.GetAllDcAndEnvs()
+	if err != nil {
+		return err
+	}
+
+	values := make(map[configPath]string, 1024)
+	for dc, envs := range dcs {
+		for _, env := range envs {
+			if err = x.scan(values, dc, env, ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	x.Lock()
+	x.values = values
+	x.ready = true
+	x.Unlock()
+	return nil
+}
+
+// refresh updates the index by the change event, which re-reads the latest
+// values of the changed keys from the backend store.
+func (x *configIndex) refresh(e store.Event) error {
+	if e.Key != "" {
+		p := configPath{Dc: e.Dc, Env: e.Env, App: e.App, Key: e.Key}
+		v, _, err := backend.AppGetConfig(e.Dc, e.Env, e.App, e.Key, 0)
+		if err != nil && err != store.ErrNotFound {
+			return err
+		}
+
+		x.Lock()
+		if err == store.ErrNotFound {
+			delete(x.values, p)
+		} else {
+			x.values[p] = v
+		}
+		x.Unlock()
+		return nil
+	}
+
+	// The event is to delete or restore the whole dc, env or app.
+	values := make(map[configPath]string, 64)
+	if !e.Deleted {
+		envs := []string{e.Env}
+		if e.Env == "" {
+			dcs, err := backend.GetAllDcAndEnvs()
+			if err != nil {
+				return err
+			}
+			envs = dcs[e.Dc]
+		}
+
+		for _, env := range envs {
+			if err := x.scan(values, e.Dc, env, e.App); err != nil {
+				return err
+			}
+		}
+	}
+
+	x.Lock()
+	for p := range x.values {
+		if p.Dc == e.Dc && (e.Env == "" || p.Env == e.Env) &&
+			(e.App == "" || p.App == e.App) {
+			delete(x.values, p)
+		}
+	}
+	for p, v := range values {
+		x.values[p] = v
+	}
+	x.Unlock()
+	return nil
+}
+
+// Run builds the index, then keeps it up to date by the change events,
+// which never returns. If failed, it rebuilds the index after interval.
+func (x *configIndex) Run(interval time.Duration) {
+	for {
+		// Subscribe before building in order not to miss any change.
+		sub := eventWatcher.Subscribe("", "", "", "")
+		err := x.build()
+		if err == nil {
+			for e := range sub.events {
+				if err = x.refresh(e); err != nil {
+					break
+				}
+			}
+		}
+		eventWatcher.Unsubscribe(sub)
+
+		if err != nil {
+			logger.Errorf("failed to update the search index: %s", err)
+		} else {
+			logger.Warnf("the search index falls behind the change events")
+		}
+		time.Sleep(interval)
+	}
+}
+
+// searchResult is a key matching the search.
+type searchResult struct {
+	Dc      string `json:"dc"`
+	Env     string `json:"env"`
+	App     string `json:"app"`
+	Key     string `json:"key"`
+	In      string `json:"in"` // "name" or "value"
+	Snippet string `json:"snippet"`
+}
+
+// getSnippet returns the part of s around the match from start to end.
+func getSnippet(s string, start, end int) string {
+	prefix, suffix := "", ""
+	if start > snippetContext {
+		start -= snippetContext
+		for !utf8.RuneStart(s[start]) {
+			start++
+		}
+		prefix = "..."
+	} else {
+		start = 0
+	}
+	if end+snippetContext < len(s) {
+		end += snippetContext
+		for !utf8.RuneStart(s[end]) {
+			end--
+		}
+		suffix = "..."
+	} else {
+		end = len(s)
+	}
+	return prefix + s[start:end] + suffix
+}
+
+// Search returns the keys in dc, env and app, the names or the latest values
+// of which are matched by match, in the order of dc, env, app and key.
+// If one of dc, env and app is "", search all of them.
+//
+// If the index has not been built, ready is false.
+func (x *configIndex) Search(match func(string) []int, inName, inValue bool,
+	dc, env, app string) (results []searchResult, ready bool) {
+	x.RLock()
+	defer x.RUnlock()
+	if !x.ready {
+		return nil, false
+	}
+
+	results = make([]searchResult, 0, 32)
+	for p, v := range x.values {
+		if (dc != "" && p.Dc != dc) || (env != "" && p.Env != env) ||
+			(app != "" && p.App != app) {
+			continue
+		}
+
+		r := searchResult{Dc: p.Dc, Env: p.Env, App: p.App, Key: p.Key}
+		if inValue {
+			if loc := match(v); loc != nil {
+				r.In = "value"
+				r.Snippet = getSnippet(v, loc[0], loc[1])
+			}
+		}
+		if inName && r.In == "" && match(p.Key) != nil {
+			r.In = "name"
+			r.Snippet = p.Key
+		}
+		if r.In != "" {
+			results = append(results, r)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Dc != b.Dc {
+			return a.Dc < b.Dc
+		} else if a.Env != b.Env {
+			return a.Env < b.Env
+		} else if a.App != b.App {
+			return a.App < b.App
+		}
+		return a.Key < b.Key
+	})
+	return results, true
+}
+
+// SearchConfig searches the keys in all the dcs, envs and apps, the names or
+// the latest values of which contain the query argument q, or match it as
+// the regular expression if regex is true.
+//
+// The query argument in is "name" or "value" to only search the names
+// or the values. The query arguments dc, env and app narrow the search.
+func SearchConfig(w http.ResponseWriter, r *http.Request) error {
+	query := r.URL.Query()
+
+	page, err := http2.GetQueryInt64(query, "page")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	size, err := http2.GetQueryInt64(query, "size")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+	if size < 1 {
+		size = 20
+	}
+
+	regex, err := getQueryBool(query, "regex")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	q := http2.GetQuery(query, "q")
+	if q == "" {
+		return http2.String(w, http.StatusBadRequest, "missing the query q")
+	}
+
+	match := func(s string) []int {
+		if i := strings.Index(s, q); i > -1 {
+			return []int{i, i + len(q)}
+		}
+		return nil
+	}
+	if regex {
+		re, err := regexp.Compile(q)
+		if err != nil {
+			return http2.Error(w, err, http.StatusBadRequest)
+		}
+		match = re.FindStringIndex
+	}
+
+	inName, inValue := true, true
+	switch in := http2.GetQuery(query, "in"); in {
+	case "":
+	case "name":
+		inValue = false
+	case "value":
+		inName = false
+	default:
+		return http2.String(w, http.StatusBadRequest,
+			"the query in must be name or value")
+	}
+
+	results, ready := searchIndex.Search(match, inName, inValue,
+		http2.GetQuery(query, "dc"), http2.GetQuery(query, "env"),
+		http2.GetQuery(query, "app"))
+	if !ready {
+		return http2.String(w, http.StatusServiceUnavailable,
+			"the search index is being built")
+	}
+
+	total := int64(len(results))
+	start, end := (page-1)*size, page*size
+	if start > total {
+		start = total
+	}
+	if end > total {
+		end = total
+	}
+	results = results[start:end]
+
+	// Never show the values of the sensitive keys.
+	for i, result := range results {
+		if result.In != "value" {
+			continue
+		}
+		md, err := backend.GetMetadata(result.Dc, result.Env, result.App,
+			result.Key)
+		if err == nil && md.Sensitive {
+			results[i].Snippet = sensitiveMask
+		} else if err != nil && err != store.ErrNotFound {
+			return renderError(w, err)
+		}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"total": total,
+		"results": results})
+}
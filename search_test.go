@@ -0,0 +1,41 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestConfigIndexSearch(t *testing.T) {
+	x := newConfigIndex()
+	x.values[configPath{"bj", "dev", "a", "db_host"}] = "old-host:3306"
+	x.values[configPath{"bj", "dev", "b", "old-host"}] = "1"
+	x.values[configPath{"sh", "dev", "a", "url"}] = "http://old-host/api"
+	match := regexp.MustCompile(`old-ho.t`).FindStringIndex
+
+	if _, ready := x.Search(match, true, true, "", "", ""); ready {
+		t.Error("expected the index not ready")
+	}
+
+	x.ready = true
+	results, _ := x.Search(match, true, true, "", "", "")
+	if len(results) != 3 || results[0].Key != "db_host" ||
+		results[1].In != "name" || results[2].Dc != "sh" {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	if results, _ = x.Search(match, false, true, "bj", "", ""); len(results) != 1 ||
+		results[0].Snippet != "old-host:3306" {
+		t.Errorf("unexpected results %+v", results)
+	}
+}
+
+func TestGetSnippet(t *testing.T) {
+	s := "0123456789012345678901234567890123456789-host-0123456789012345678901234567890123456789"
+	expected := "...89012345678901234567890123456789-host-01234567890123456789012345678901..."
+	if snippet := getSnippet(s, 40, 46); snippet != expected {
+		t.Errorf("expected %q, got %q", expected, snippet)
+	}
+	if snippet := getSnippet("a-host-b", 1, 7); snippet != "a-host-b" {
+		t.Errorf("expected %q, got %q", "a-host-b", snippet)
+	}
+}
@@ -21,6 +21,17 @@ type memoryStore struct {
 	keys      map[string]map[int64]string
 	callbacks map[string]map[string]string
 	results   map[string]map[string][][3]string
+	rollbacks map[string][]Rollback
+	trashes   map[string]*memoryTrash
+	tags      map[string]map[string]Tag
+	drafts    map[string]Draft
+	changes   map[string]Change
+	schedules map[string]Schedule
+	canaries  map[string]Canary
+	deps      map[string][]string
+	metadata  map[string]Metadata
+	events    []Event
+	lastEvent int64
 }
 
 // NewMemoryStore returns a new MemoryStore.
@@ -29,39 +40,49 @@ func NewMemoryStore() Store {
 		keys:      make(map[string]map[int64]string),
 		callbacks: make(map[string]map[string]string),
 		results:   make(map[string]map[string][][3]string),
+		rollbacks: make(map[string][]Rollback),
+		trashes:   make(map[string]*memoryTrash),
+		tags:      make(map[string]map[string]Tag),
+		drafts:    make(map[string]Draft),
+		changes:   make(map[string]Change),
+		schedules: make(map[string]Schedule),
+		canaries:  make(map[string]Canary),
+		deps:      make(map[string][]string),
+		metadata:  make(map[string]Metadata),
 	}
 
 	return m
 }
 
-func (m *memoryStore) getLastestValue(ms map[int64]string) (string, error) {
+func (m *memoryStore) getLastestValue(ms map[int64]string) (string, int64,
+	error) {
 	_len := len(ms)
 	if _len == 0 {
-		return "", ErrNotFound
+		return "", 0, ErrNotFound
 	}
 	vs := make([]int, 0, _len)
 	for key := range ms {
 		vs = append(vs, int(key))
 	}
 	sort.Sort(sort.Reverse(sort.IntSlice(vs)))
-	return ms[int64(vs[0])], nil
+	return ms[int64(vs[0])], int64(vs[0]), nil
 }
 
 func (m *memoryStore) AppGetConfig(dc, env, app, key string, _time int64) (
-	string, error) {
+	string, int64, error) {
 	m.Lock()
 	defer m.Unlock()
 
 	k := m.getKey(dc, env, app, key)
 	vs := m.keys[k]
 	if vs == nil {
-		return "", ErrNotFound
+		return "", 0, ErrNotFound
 	}
 	if _time != 0 {
 		if v, ok := vs[_time]; ok {
-			return v, nil
+			return v, _time, nil
 		}
-		return "", ErrNotFound
+		return "", 0, ErrNotFound
 	}
 
 	return m.getLastestValue(vs)
@@ -72,6 +93,13 @@ func (m *memoryStore) DeleteConfig(dc, env, app, key string, _time int64) error
 		return ErrNotFound
 	}
 
+	// Clear the rest if deleting the whole dc or env.
+	if env == "" {
+		app, key = "", ""
+	} else if app == "" {
+		key = ""
+	}
+
 	m.Lock()
 	defer m.Unlock()
 
@@ -85,12 +113,17 @@ func (m *memoryStore) DeleteConfig(dc, env, app, key string, _time int64) error
 	} else if _time == 0 {
 		prefix = m.getKey(dc, env, app, key)
 		delete(m.keys, prefix)
+		delete(m.rollbacks, prefix)
+		delete(m.metadata, prefix)
+		m.moveRecords(func(k string) bool { return k == prefix }, nil)
+		m.addEvent(dc, env, app, key, 0, "", true)
 		return nil
 	} else {
 		prefix = m.getKey(dc, env, app, key)
 		if vs := m.keys[prefix]; vs != nil {
 			delete(vs, _time)
 		}
+		m.addEvent(dc, env, app, key, _time, "", true)
 		return nil
 	}
 
@@ -104,9 +137,60 @@ func (m *memoryStore) DeleteConfig(dc, env, app, key string, _time int64) error
 	for _, key := range keys {
 		delete(m.keys, key)
 	}
+	for key := range m.rollbacks {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.rollbacks, key)
+		}
+	}
+	for key := range m.metadata {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.metadata, key)
+		}
+	}
+	m.moveRecords(m.hasPrefix(prefix), nil)
+	m.addEvent(dc, env, app, key, 0, "", true)
 	return nil
 }
 
+// moveRecords moves the drafts, the canaries, the schedules and the tags
+// of the keys matched by match into the trash mt, or deletes them if mt is
+// nil. The tags are matched by the prefix of the app, so they are only moved
+// with the whole app.
+func (m *memoryStore) moveRecords(match func(string) bool, mt *memoryTrash) {
+	for k, d := range m.drafts {
+		if match(k) {
+			if mt != nil {
+				mt.drafts[k] = d
+			}
+			delete(m.drafts, k)
+		}
+	}
+	for k, c := range m.canaries {
+		if match(k) {
+			if mt != nil {
+				mt.canaries[k] = c
+			}
+			delete(m.canaries, k)
+		}
+	}
+	for id, s := range m.schedules {
+		if match(m.getKey(s.Dc, s.Env, s.App, s.Key)) {
+			if mt != nil {
+				mt.schedules[id] = s
+			}
+			delete(m.schedules, id)
+		}
+	}
+	for k, ts := range m.tags {
+		if match(k) {
+			if mt != nil {
+				mt.tags[k] = ts
+			}
+			delete(m.tags, k)
+		}
+	}
+}
+
 func (m *memoryStore) CreateDcAndEnv(dc, env string) error {
 	m.Lock()
 	defer m.Unlock()
@@ -134,6 +218,714 @@ func (m *memoryStore) GetAllDcAndEnvs() (map[string][]string, error) {
 	return ms, nil
 }
 
+func (m *memoryStore) MoveConfig(dc, env, app, key, toApp, toKey string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	// The map from the old key to the new key.
+	moved := make(map[string]string, 4)
+	if key != "" {
+		if k := m.getKey(dc, env, app, key); m.keys[k] != nil {
+			moved[k] = m.getKey(dc, env, toApp, toKey)
+		}
+	} else {
+		prefix := m.getPrefix([]string{dc, env, app})
+		for k := range m.keys {
+			if strings.HasPrefix(k, prefix) {
+				_, _, _, _key := m.splitKey(k)
+				moved[k] = m.getKey(dc, env, toApp, _key)
+			}
+		}
+	}
+
+	if len(moved) == 0 {
+		return ErrNotFound
+	}
+	for _, to := range moved {
+		if _, ok := m.keys[to]; ok {
+			return ErrExist
+		} else if _, ok := m.drafts[to]; ok {
+			return ErrExist
+		} else if _, ok := m.canaries[to]; ok {
+			return ErrExist
+		} else if _, ok := m.metadata[to]; ok {
+			return ErrExist
+		}
+	}
+
+	for from, to := range moved {
+		m.keys[to] = m.keys[from]
+		delete(m.keys, from)
+
+		if cs, ok := m.callbacks[from]; ok {
+			m.callbacks[to] = cs
+			delete(m.callbacks, from)
+		}
+		if rs, ok := m.results[from]; ok {
+			m.results[to] = rs
+			delete(m.results, from)
+		}
+		if rs, ok := m.rollbacks[from]; ok {
+			m.rollbacks[to] = rs
+			delete(m.rollbacks, from)
+		}
+
+		_, _, _app, _key := m.splitKey(to)
+		if d, ok := m.drafts[from]; ok {
+			d.Key = _key
+			m.drafts[to] = d
+			delete(m.drafts, from)
+		}
+		if c, ok := m.canaries[from]; ok {
+			c.App, c.Key = _app, _key
+			m.canaries[to] = c
+			delete(m.canaries, from)
+		}
+		if md, ok := m.metadata[from]; ok {
+			md.App, md.Key = _app, _key
+			m.metadata[to] = md
+			delete(m.metadata, from)
+		}
+		for id, s := range m.schedules {
+			if m.getKey(s.Dc, s.Env, s.App, s.Key) == from {
+				s.App, s.Key = _app, _key
+				m.schedules[id] = s
+			}
+		}
+	}
+
+	m.addEvent(dc, env, app, key, 0, "", true)
+	for _, to := range moved {
+		_, _, _app, _key := m.splitKey(to)
+		value, version, _ := m.getLastestValue(m.keys[to])
+		m.addEvent(dc, env, _app, _key, version, value, false)
+	}
+	return nil
+}
+
+func (m *memoryStore) AddChange(change Change) (Change, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	change.ID = newID(now)
+	change.Time = now.Unix()
+	change.Status = ChangePending
+	change.Revision = 1
+	m.changes[change.ID] = change
+	return change, nil
+}
+
+func (m *memoryStore) GetChanges(dc, env, status string) ([]Change, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	changes := make([]Change, 0, len(m.changes))
+	for _, c := range m.changes {
+		if (dc == "" || c.Dc == dc) && (env == "" || c.Env == env) &&
+			(status == "" || c.Status == status) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Time == changes[j].Time {
+			return changes[i].ID < changes[j].ID
+		}
+		return changes[i].Time < changes[j].Time
+	})
+	return changes, nil
+}
+
+func (m *memoryStore) GetChange(id string) (Change, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.changes[id]
+	if !ok {
+		return Change{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (m *memoryStore) UpdateChange(change Change) (Change, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.changes[change.ID]
+	if !ok {
+		return Change{}, ErrNotFound
+	} else if c.Revision != change.Revision {
+		return Change{}, ErrConflict
+	}
+
+	change.Approvers = append([]string(nil), change.Approvers...)
+	change.Revision++
+	m.changes[change.ID] = change
+	return change, nil
+}
+
+func (m *memoryStore) AddSchedule(schedule Schedule) (Schedule, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now()
+	schedule.ID = newID(now)
+	schedule.Time = now.Unix()
+	m.schedules[schedule.ID] = schedule
+	return schedule, nil
+}
+
+func (m *memoryStore) GetSchedules(dc, env, app string) ([]Schedule, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	schedules := make([]Schedule, 0, len(m.schedules))
+	for _, s := range m.schedules {
+		if (dc == "" || s.Dc == dc) && (env == "" || s.Env == env) &&
+			(app == "" || s.App == app) {
+			schedules = append(schedules, s)
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+func (m *memoryStore) DeleteSchedule(id string) (Schedule, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return Schedule{}, ErrNotFound
+	}
+	delete(m.schedules, id)
+	return s, nil
+}
+
+func (m *memoryStore) ApplySchedule(id string) (Schedule, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return Schedule{}, ErrNotFound
+	}
+	delete(m.schedules, id)
+
+	now := time.Now().Unix()
+	k := m.getKey(s.Dc, s.Env, s.App, s.Key)
+	if vs := m.keys[k]; vs != nil {
+		vs[now] = s.Value
+	} else {
+		m.keys[k] = map[int64]string{now: s.Value}
+	}
+	m.addEvent(s.Dc, s.Env, s.App, s.Key, now, s.Value, false)
+	return s, nil
+}
+
+func (m *memoryStore) AddCanary(canary Canary) (Canary, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(canary.Dc, canary.Env, canary.App, canary.Key)
+	if _, ok := m.canaries[k]; ok {
+		return Canary{}, ErrExist
+	}
+	canary.Time = time.Now().Unix()
+	m.canaries[k] = canary
+	return canary, nil
+}
+
+func (m *memoryStore) UpdateCanary(canary Canary) (Canary, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(canary.Dc, canary.Env, canary.App, canary.Key)
+	if _, ok := m.canaries[k]; !ok {
+		return Canary{}, ErrNotFound
+	}
+	canary.Time = time.Now().Unix()
+	m.canaries[k] = canary
+	return canary, nil
+}
+
+func (m *memoryStore) GetCanaries(dc, env, app string) ([]Canary, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	canaries := make([]Canary, 0, 8)
+	for k, c := range m.canaries {
+		if strings.HasPrefix(k, prefix) {
+			canaries = append(canaries, c)
+		}
+	}
+	sort.Slice(canaries, func(i, j int) bool {
+		return canaries[i].Key < canaries[j].Key
+	})
+	return canaries, nil
+}
+
+func (m *memoryStore) GetCanary(dc, env, app, key string) (Canary, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	c, ok := m.canaries[m.getKey(dc, env, app, key)]
+	if !ok {
+		return Canary{}, ErrNotFound
+	}
+	return c, nil
+}
+
+func (m *memoryStore) DeleteCanary(dc, env, app, key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(dc, env, app, key)
+	if _, ok := m.canaries[k]; !ok {
+		return ErrNotFound
+	}
+	delete(m.canaries, k)
+	return nil
+}
+
+func (m *memoryStore) SetDependencies(dc, env, app string,
+	namespaces []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	k := strings.Join([]string{dc, env, app}, "/")
+	if len(namespaces) == 0 {
+		delete(m.deps, k)
+	} else {
+		m.deps[k] = append([]string(nil), namespaces...)
+	}
+	return nil
+}
+
+func (m *memoryStore) GetDependencies(dc, env, app string) ([]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	namespaces := m.deps[strings.Join([]string{dc, env, app}, "/")]
+	return append([]string(nil), namespaces...), nil
+}
+
+func (m *memoryStore) GetDependents(dc, env, namespace string) ([]string,
+	error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env})
+	apps := make([]string, 0, 8)
+	for k, namespaces := range m.deps {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		for _, ns := range namespaces {
+			if ns == namespace {
+				apps = append(apps, strings.TrimPrefix(k, prefix))
+				break
+			}
+		}
+	}
+	sort.Strings(apps)
+	return apps, nil
+}
+
+func (m *memoryStore) SetMetadata(metadata Metadata) (Metadata, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	metadata.Time = time.Now().Unix()
+	m.metadata[m.getKey(metadata.Dc, metadata.Env, metadata.App,
+		metadata.Key)] = metadata
+	return metadata, nil
+}
+
+func (m *memoryStore) GetMetadata(dc, env, app, key string) (Metadata, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	metadata, ok := m.metadata[m.getKey(dc, env, app, key)]
+	if !ok {
+		return Metadata{}, ErrNotFound
+	}
+	return metadata, nil
+}
+
+func (m *memoryStore) GetAllMetadata(dc, env, app string) (
+	map[string]Metadata, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	metadata := make(map[string]Metadata, 8)
+	for k, md := range m.metadata {
+		if strings.HasPrefix(k, prefix) {
+			metadata[md.Key] = md
+		}
+	}
+	return metadata, nil
+}
+
+func (m *memoryStore) DeleteMetadata(dc, env, app, key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(dc, env, app, key)
+	if _, ok := m.metadata[k]; !ok {
+		return ErrNotFound
+	}
+	delete(m.metadata, k)
+	return nil
+}
+
+func (m *memoryStore) SetDraft(dc, env, app, key, value string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	m.drafts[m.getKey(dc, env, app, key)] = Draft{Key: key, Value: value,
+		Time: time.Now().Unix()}
+	return nil
+}
+
+func (m *memoryStore) GetDrafts(dc, env, app string) ([]Draft, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	drafts := make([]Draft, 0, 8)
+	for k, draft := range m.drafts {
+		if strings.HasPrefix(k, prefix) {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool { return drafts[i].Key < drafts[j].Key })
+	return drafts, nil
+}
+
+func (m *memoryStore) DeleteDrafts(dc, env, app, key string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	var n int
+	prefix := m.getPrefix([]string{dc, env, app})
+	for k := range m.drafts {
+		if (key == "" && strings.HasPrefix(k, prefix)) || k == prefix+key {
+			delete(m.drafts, k)
+			n++
+		}
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *memoryStore) PublishDrafts(dc, env, app string) (map[string]Version,
+	error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := time.Now().Unix()
+	prefix := m.getPrefix([]string{dc, env, app})
+	published := make(map[string]Version, 8)
+	for k, draft := range m.drafts {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+
+		if vs := m.keys[k]; vs != nil {
+			vs[now] = draft.Value
+		} else {
+			m.keys[k] = map[int64]string{now: draft.Value}
+		}
+		delete(m.drafts, k)
+		published[draft.Key] = Version{Time: now, Value: draft.Value}
+		m.addEvent(dc, env, app, draft.Key, now, draft.Value, false)
+	}
+
+	if len(published) == 0 {
+		return nil, ErrNotFound
+	}
+	return published, nil
+}
+
+func (m *memoryStore) CreateTag(dc, env, app, name string) (Tag, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	if _, ok := m.tags[prefix][name]; ok {
+		return Tag{}, ErrExist
+	}
+
+	tag := Tag{Name: name, Time: time.Now().Unix(),
+		Versions: make(map[string]int64, 8)}
+	for k, vs := range m.keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if _, _, _, key := m.splitKey(k); key != "" {
+			if _, version, err := m.getLastestValue(vs); err == nil {
+				tag.Versions[key] = version
+			}
+		}
+	}
+	if len(tag.Versions) == 0 {
+		return Tag{}, ErrNotFound
+	}
+
+	if m.tags[prefix] == nil {
+		m.tags[prefix] = make(map[string]Tag, 4)
+	}
+	m.tags[prefix][name] = tag
+	return tag, nil
+}
+
+func (m *memoryStore) GetTags(dc, env, app string) ([]Tag, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	ts := m.tags[m.getPrefix([]string{dc, env, app})]
+	tags := make([]Tag, 0, len(ts))
+	for _, tag := range ts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Time == tags[j].Time {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].Time < tags[j].Time
+	})
+	return tags, nil
+}
+
+func (m *memoryStore) GetTag(dc, env, app, name string) (Tag, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	tag, ok := m.tags[m.getPrefix([]string{dc, env, app})][name]
+	if !ok {
+		return Tag{}, ErrNotFound
+	}
+	return tag, nil
+}
+
+func (m *memoryStore) DeleteTag(dc, env, app, name string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	if _, ok := m.tags[prefix][name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.tags[prefix], name)
+	if len(m.tags[prefix]) == 0 {
+		delete(m.tags, prefix)
+	}
+	return nil
+}
+
+// memoryTrash is the config in the trash, the key of the maps in which is
+// the same as memoryStore.
+type memoryTrash struct {
+	Trash
+	keys      map[string]map[int64]string
+	callbacks map[string]map[string]string
+	results   map[string]map[string][][3]string
+	rollbacks map[string][]Rollback
+	metadata  map[string]Metadata
+	drafts    map[string]Draft
+	canaries  map[string]Canary
+	schedules map[string]Schedule
+	tags      map[string]map[string]Tag
+}
+
+func (m *memoryStore) TrashConfig(dc, env, app, key string) (Trash, error) {
+	if dc == "" {
+		return Trash{}, ErrNotFound
+	}
+
+	trash := newTrash(dc, env, app, key)
+	mt := &memoryTrash{
+		Trash:     trash,
+		keys:      make(map[string]map[int64]string, 4),
+		callbacks: make(map[string]map[string]string, 4),
+		results:   make(map[string]map[string][][3]string, 4),
+		rollbacks: make(map[string][]Rollback, 4),
+		metadata:  make(map[string]Metadata, 4),
+		drafts:    make(map[string]Draft, 4),
+		canaries:  make(map[string]Canary, 4),
+		schedules: make(map[string]Schedule, 4),
+		tags:      make(map[string]map[string]Tag, 4),
+	}
+
+	var match func(string) bool
+	if trash.Key != "" {
+		k := m.getKey(dc, env, app, key)
+		match = func(s string) bool { return s == k }
+	} else if trash.App != "" {
+		match = m.hasPrefix(m.getPrefix([]string{dc, env, app}))
+	} else if trash.Env != "" {
+		match = m.hasPrefix(m.getPrefix([]string{dc, env}))
+	} else {
+		match = m.hasPrefix(m.getPrefix([]string{dc}))
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for k, vs := range m.keys {
+		if match(k) {
+			mt.keys[k] = vs
+			delete(m.keys, k)
+		}
+	}
+	if len(mt.keys) == 0 {
+		return Trash{}, ErrNotFound
+	}
+
+	for k, cs := range m.callbacks {
+		if match(k) {
+			mt.callbacks[k] = cs
+			delete(m.callbacks, k)
+		}
+	}
+	for k, rs := range m.results {
+		if match(k) {
+			mt.results[k] = rs
+			delete(m.results, k)
+		}
+	}
+	for k, rs := range m.rollbacks {
+		if match(k) {
+			mt.rollbacks[k] = rs
+			delete(m.rollbacks, k)
+		}
+	}
+	for k, md := range m.metadata {
+		if match(k) {
+			mt.metadata[k] = md
+			delete(m.metadata, k)
+		}
+	}
+	m.moveRecords(match, mt)
+
+	m.trashes[trash.ID] = mt
+	m.addEvent(trash.Dc, trash.Env, trash.App, trash.Key, 0, "", true)
+	return trash, nil
+}
+
+func (m *memoryStore) GetTrashes() ([]Trash, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	trashes := make([]Trash, 0, len(m.trashes))
+	for _, mt := range m.trashes {
+		trashes = append(trashes, mt.Trash)
+	}
+	sort.Slice(trashes, func(i, j int) bool {
+		if trashes[i].Time == trashes[j].Time {
+			return trashes[i].ID < trashes[j].ID
+		}
+		return trashes[i].Time < trashes[j].Time
+	})
+	return trashes, nil
+}
+
+func (m *memoryStore) RestoreTrash(id string) (Trash, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	mt, ok := m.trashes[id]
+	if !ok {
+		return Trash{}, ErrNotFound
+	}
+
+	// The placeholder of dc and env, the app of which is "", does not conflict.
+	for k := range mt.keys {
+		if _, _, app, _ := m.splitKey(k); app != "" {
+			if _, ok := m.keys[k]; ok {
+				return Trash{}, ErrExist
+			}
+		}
+	}
+	for k := range mt.drafts {
+		if _, ok := m.drafts[k]; ok {
+			return Trash{}, ErrExist
+		}
+	}
+	for k := range mt.canaries {
+		if _, ok := m.canaries[k]; ok {
+			return Trash{}, ErrExist
+		}
+	}
+	for k, ts := range mt.tags {
+		for name := range ts {
+			if _, ok := m.tags[k][name]; ok {
+				return Trash{}, ErrExist
+			}
+		}
+	}
+
+	for k, vs := range mt.keys {
+		if _, ok := m.keys[k]; !ok {
+			m.keys[k] = vs
+		}
+	}
+	for k, cs := range mt.callbacks {
+		m.callbacks[k] = cs
+	}
+	for k, rs := range mt.results {
+		m.results[k] = rs
+	}
+	for k, rs := range mt.rollbacks {
+		m.rollbacks[k] = rs
+	}
+	for k, md := range mt.metadata {
+		m.metadata[k] = md
+	}
+	for k, d := range mt.drafts {
+		m.drafts[k] = d
+	}
+	for k, c := range mt.canaries {
+		m.canaries[k] = c
+	}
+	for id, s := range mt.schedules {
+		m.schedules[id] = s
+	}
+	for k, ts := range mt.tags {
+		if m.tags[k] == nil {
+			m.tags[k] = make(map[string]Tag, len(ts))
+		}
+		for name, tag := range ts {
+			m.tags[k][name] = tag
+		}
+	}
+	delete(m.trashes, id)
+
+	for k, vs := range mt.keys {
+		if dc, env, app, key := m.splitKey(k); key != "" {
+			value, version, _ := m.getLastestValue(vs)
+			m.addEvent(dc, env, app, key, version, value, false)
+		}
+	}
+	return mt.Trash, nil
+}
+
+func (m *memoryStore) PurgeTrash(id string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.trashes[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.trashes, id)
+	return nil
+}
+
 func (m *memoryStore) SetKeyValue(dc, env, app, key, value string) error {
 	m.Lock()
 	defer m.Unlock()
@@ -145,6 +937,31 @@ func (m *memoryStore) SetKeyValue(dc, env, app, key, value string) error {
 	} else {
 		m.keys[k] = map[int64]string{now: value}
 	}
+	m.addEvent(dc, env, app, key, now, value, false)
+	return nil
+}
+
+func (m *memoryStore) SetKeyValues(dc, env, app, key string,
+	values map[int64]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(dc, env, app, key)
+	vs := m.keys[k]
+	if vs == nil {
+		vs = make(map[int64]string, len(values))
+		m.keys[k] = vs
+	}
+	for t, v := range values {
+		vs[t] = v
+	}
+
+	value, version, _ := m.getLastestValue(values)
+	m.addEvent(dc, env, app, key, version, value, false)
 	return nil
 }
 
@@ -278,6 +1095,100 @@ func (m *memoryStore) GetAllValues(dc, env, app, key string, page, number, from,
 	return total, _values, nil
 }
 
+func (m *memoryStore) GetConfigAsOf(dc, env, app, key string, at int64) (
+	map[string]Version, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	prefix := m.getPrefix([]string{dc, env, app})
+	result := make(map[string]Version, 8)
+	for k, vs := range m.keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		_, _, _, _key := m.splitKey(k)
+		if _key == "" || (key != "" && _key != key) {
+			continue
+		}
+
+		var version Version
+		for t, v := range vs {
+			if t <= at && t > version.Time {
+				version = Version{Time: t, Value: v}
+			}
+		}
+		if version.Time > 0 {
+			result[_key] = version
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryStore) RollbackKey(dc, env, app, key string, _time int64) (
+	int64, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	k := m.getKey(dc, env, app, key)
+	value, ok := m.keys[k][_time]
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	now := time.Now().Unix()
+	m.keys[k][now] = value
+	m.rollbacks[k] = append(m.rollbacks[k], Rollback{Time: now, Target: _time})
+	m.addEvent(dc, env, app, key, now, value, false)
+	return now, nil
+}
+
+func (m *memoryStore) GetRollbacks(dc, env, app, key string) ([]Rollback,
+	error) {
+	m.Lock()
+	defer m.Unlock()
+
+	rs := m.rollbacks[m.getKey(dc, env, app, key)]
+	result := make([]Rollback, len(rs))
+	for i, r := range rs {
+		result[len(rs)-1-i] = r
+	}
+	return result, nil
+}
+
+// addEvent records a change event, which must be called with the lock.
+func (m *memoryStore) addEvent(dc, env, app, key string, _time int64,
+	value string, deleted bool) {
+
+	m.lastEvent++
+	m.events = append(m.events, Event{ID: m.lastEvent, Dc: dc, Env: env,
+		App: app, Key: key, Time: _time, Value: value, Deleted: deleted})
+
+	// Discard the old events, but avoid copying them each time.
+	if len(m.events) > maxEvents+maxEvents/10 {
+		m.events = append([]Event(nil), m.events[len(m.events)-maxEvents:]...)
+	}
+}
+
+func (m *memoryStore) GetEvents(since, number int64) ([]Event, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	start := sort.Search(len(m.events), func(i int) bool {
+		return m.events[i].ID > since
+	})
+	end := len(m.events)
+	if number > 0 && int64(end-start) > number {
+		end = start + int(number)
+	}
+	return append([]Event(nil), m.events[start:end]...), nil
+}
+
+func (m *memoryStore) GetLastEventID() (int64, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.lastEvent, nil
+}
+
 func (m *memoryStore) AddCallback(dc, env, app, key, id, callback string) error {
 	key = m.getKey(dc, env, app, key)
 	m.Lock()
@@ -291,14 +1202,19 @@ func (m *memoryStore) AddCallback(dc, env, app, key, id, callback string) error
 }
 
 func (m *memoryStore) GetCallback(dc, env, app, key string) (map[string]string, error) {
-	key = m.getKey(dc, env, app, env)
+	key = m.getKey(dc, env, app, key)
 	m.Lock()
+	defer m.Unlock()
 	s, ok := m.callbacks[key]
-	m.Unlock()
-	if ok {
-		return s, nil
+	if !ok {
+		return nil, ErrNotFound
 	}
-	return nil, ErrNotFound
+
+	result := make(map[string]string, len(s))
+	for id, cb := range s {
+		result[id] = cb
+	}
+	return result, nil
 }
 
 func (m *memoryStore) DeleteCallback(dc, env, app, key, id string) error {
@@ -367,6 +1283,10 @@ func (m *memoryStore) getPrefix(ss []string) string {
 	return strings.Join(ss, "/")
 }
 
+func (m *memoryStore) hasPrefix(prefix string) func(string) bool {
+	return func(s string) bool { return strings.HasPrefix(s, prefix) }
+}
+
 func (m *memoryStore) getKey(dc, env, app, key string) string {
 	return strings.Join([]string{dc, env, app, key}, "/")
 }
@@ -1,7 +1,9 @@
 package store
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,30 +23,64 @@ type sqlStore struct {
 	table   string
 	cbtable string
 	crtable string
+	evtable string
+	rbtable string
+	trtable string
+	tgtable string
+	drtable string
+	chtable string
+	sctable string
+	cntable string
+	dptable string
+	mdtable string
 	engine  *xorm.Engine
 }
 
 // NewSQLStore returns a new store backend based on SQL.
+//
+// table is the names of the tables in turn: the config, the callback,
+// the callback result, the change event, the rollback, the trash, the tag,
+// the draft, the change request, the schedule, the canary, the namespace
+// dependency and the key metadata. The default is "appconfig", "appcallback",
+// "appresult", "appevent", "approllback", "apptrash", "apptag", "appdraft",
+// "appchange", "appschedule", "appcanary", "appdependency" and "appmetadata".
 func NewSQLStore(driver string, table ...string) Store {
-	tableName := "appconfig"
-	cbtableName := "appcallback"
-	cbResultTableName := "appresult"
-	if len(table) == 1 {
-		tableName = table[0]
-	} else if len(table) == 2 {
-		tableName = table[0]
-		cbtableName = table[1]
-	} else if len(table) > 2 {
-		tableName = table[0]
-		cbtableName = table[1]
-		cbResultTableName = table[2]
-	}
+	tables := []string{"appconfig", "appcallback", "appresult", "appevent",
+		"approllback", "apptrash", "apptag", "appdraft", "appchange",
+		"appschedule", "appcanary", "appdependency", "appmetadata"}
+	copy(tables, table)
 	return &sqlStore{
 		driver:  driver,
-		table:   tableName,
-		cbtable: cbtableName,
-		crtable: cbResultTableName,
+		table:   tables[0],
+		cbtable: tables[1],
+		crtable: tables[2],
+		evtable: tables[3],
+		rbtable: tables[4],
+		trtable: tables[5],
+		tgtable: tables[6],
+		drtable: tables[7],
+		chtable: tables[8],
+		sctable: tables[9],
+		cntable: tables[10],
+		dptable: tables[11],
+		mdtable: tables[12],
+	}
+}
+
+// transact executes f in a transaction, which is committed if f returns nil,
+// or rolled back.
+func (s *sqlStore) transact(f func(*xorm.Session) error) error {
+	session := s.engine.NewSession()
+	defer session.Close()
+
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := f(session); err != nil {
+		session.Rollback()
+		return err
 	}
+	return session.Commit()
 }
 
 func (s *sqlStore) Init(conf string) (err error) {
@@ -118,9 +154,9 @@ func (s *sqlStore) Init(conf string) (err error) {
 // If the time is 0 or negative, it should return the latest value.
 // Or it should return the value at the provided time.
 func (s *sqlStore) AppGetConfig(dc, env, app, key string, _time int64) (
-	v string, err error) {
+	v string, version int64, err error) {
 
-	session := s.engine.Select("`value`").Table(s.table)
+	session := s.engine.Select("`time`, `value`").Table(s.table)
 	if _time > 0 {
 		where := "`dc`=? AND `env`=? AND `app`=? AND `key`=? AND `time`=?"
 		session = session.Where(where, dc, env, app, key, _time)
@@ -129,13 +165,17 @@ func (s *sqlStore) AppGetConfig(dc, env, app, key string, _time int64) (
 		session = session.Where(where, dc, env, app, key).Desc("`time`")
 	}
 
-	if ok, err := session.Get(&v); err != nil {
-		return "", err
-	} else if !ok {
-		return "", ErrNotFound
+	vs, err := session.Limit(1).QueryString()
+	if err != nil {
+		return "", 0, err
+	} else if len(vs) == 0 {
+		return "", 0, ErrNotFound
 	}
 
-	return
+	if version, err = types.ToInt64(vs[0]["time"]); err != nil {
+		return "", 0, err
+	}
+	return vs[0]["value"], version, nil
 }
 
 // CreateDcAndEnv creates the new dc and env.
@@ -167,30 +207,59 @@ func (s *sqlStore) DeleteConfig(dc, env, app, key string, _time int64) error {
 	args := make([]interface{}, 0, 5)
 	where := "`dc`=?"
 	args = append(args, dc)
+	event := [4]string{dc}
+	var version int64
 
 	if env != "" {
 		where += " AND `env`=?"
 		args = append(args, env)
+		event[1] = env
 
 		if app != "" {
 			where += " AND `app`=?"
 			args = append(args, app)
+			event[2] = app
 
 			if key != "" {
 				where += " AND `key`=?"
 				args = append(args, key)
+				event[3] = key
 
 				if _time > 0 {
 					where += " AND `time`=?"
 					args = append(args, _time)
+					version = _time
 				}
 			}
 		}
 	}
 
-	sql := fmt.Sprintf("DELETE FROM `%s` WHERE %s", s.table, where)
-	_, err := s.engine.Exec(sql, args...)
-	return err
+	return s.transact(func(session *xorm.Session) error {
+		sql := fmt.Sprintf("DELETE FROM `%s` WHERE %s", s.table, where)
+		if _, err := session.Exec(sql, args...); err != nil {
+			return err
+		}
+
+		// Delete the records of the rollback, the metadata, the draft,
+		// the canary and the schedule of the whole key, and the tags
+		// of the whole app.
+		if version == 0 {
+			tables := []string{s.rbtable, s.mdtable, s.drtable, s.cntable,
+				s.sctable}
+			if key == "" {
+				tables = append(tables, s.tgtable)
+			}
+			for _, table := range tables {
+				sql = fmt.Sprintf("DELETE FROM `%s` WHERE %s", table, where)
+				if _, err := session.Exec(sql, args...); err != nil {
+					return err
+				}
+			}
+		}
+
+		return s.addEvent(session, event[0], event[1], event[2], event[3],
+			version, "", true)
+	})
 }
 
 // GetAllDcAndEnvs returns all dc and env. The key is dc, and the value is
@@ -226,12 +295,1053 @@ func (s *sqlStore) GetAllDcAndEnvs() (map[string][]string, error) {
 	return result, nil
 }
 
+// MoveConfig moves the key of app, or the whole app if key is "",
+// in a transaction.
+func (s *sqlStore) MoveConfig(dc, env, app, key, toApp, toKey string) error {
+	from := "`dc`=? AND `env`=? AND `app`=?"
+	to := "`dc`=? AND `env`=? AND `app`=?"
+	fromArgs := []interface{}{dc, env, app}
+	toArgs := []interface{}{dc, env, toApp}
+	set := "`app`=?"
+	setArgs := []interface{}{toApp}
+	if key != "" {
+		from += " AND `key`=?"
+		to += " AND `key`=?"
+		fromArgs = append(fromArgs, key)
+		toArgs = append(toArgs, toKey)
+		set += ", `key`=?"
+		setArgs = append(setArgs, toKey)
+	}
+
+	return s.transact(func(session *xorm.Session) error {
+		for _, table := range []string{s.table, s.drtable, s.cntable, s.mdtable} {
+			vs, err := session.Select("`id`").Table(table).Where(to,
+				toArgs...).Limit(1).QueryString()
+			if err != nil {
+				return err
+			} else if len(vs) > 0 {
+				return ErrExist
+			}
+		}
+
+		tables := []string{s.table, s.cbtable, s.crtable, s.rbtable, s.drtable,
+			s.sctable}
+		for _, table := range tables {
+			sql := fmt.Sprintf("UPDATE `%s` SET %s WHERE %s", table, set, from)
+			r, err := session.Exec(sql, append(setArgs, fromArgs...)...)
+			if err != nil {
+				return err
+			}
+			if table == s.table {
+				if n, err := r.RowsAffected(); err != nil {
+					return err
+				} else if n == 0 {
+					return ErrNotFound
+				}
+			}
+		}
+
+		// The canaries and the metadata hold their app and key in data, too.
+		for _, table := range []string{s.cntable, s.mdtable} {
+			vs, err := session.Select("`id`, `key`, `data`").Table(table).Where(
+				from, fromArgs...).QueryString()
+			if err != nil {
+				return err
+			}
+			for _, v := range vs {
+				_key := v["key"]
+				if key != "" {
+					_key = toKey
+				}
+				data, err := moveData([]byte(v["data"]), toApp, _key)
+				if err != nil {
+					return err
+				}
+				sql := fmt.Sprintf("UPDATE `%s` SET `app`=?, `key`=?, `data`=? "+
+					"WHERE `id`=?", table)
+				_, err = session.Exec(sql, toApp, _key, string(data), v["id"])
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.addEvent(session, dc, env, app, key, 0, "", true); err != nil {
+			return err
+		}
+
+		// Record the latest value of each moved key.
+		vs, err := session.Select("`key`, `time`, `value`").Table(s.table).Where(
+			to, toArgs...).Asc("`time`").QueryString()
+		if err != nil {
+			return err
+		}
+		latest := make(map[string]map[string]string, len(vs))
+		keys := make([]string, 0, len(vs))
+		for _, v := range vs {
+			if _, ok := latest[v["key"]]; !ok {
+				keys = append(keys, v["key"])
+			}
+			latest[v["key"]] = v
+		}
+		for _, k := range keys {
+			v := latest[k]
+			t, err := types.ToInt64(v["time"])
+			if err != nil {
+				return err
+			}
+			err = s.addEvent(session, dc, env, toApp, k, t, v["value"], false)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddChange inserts the change, the whole of which is saved as JSON.
+func (s *sqlStore) AddChange(change Change) (Change, error) {
+	now := time.Now()
+	change.ID = newID(now)
+	change.Time = now.Unix()
+	change.Status = ChangePending
+	change.Revision = 1
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return Change{}, err
+	}
+
+	q := "INSERT INTO `%s`(`id`,`dc`,`env`,`status`,`time`,`revision`,`data`) VALUES(?,?,?,?,?,?,?)"
+	_, err = s.engine.Exec(fmt.Sprintf(q, s.chtable), change.ID, change.Dc,
+		change.Env, change.Status, change.Time, change.Revision, string(data))
+	if err != nil {
+		return Change{}, err
+	}
+	return change, nil
+}
+
+// GetChanges returns the changes in the ascending order of the time.
+func (s *sqlStore) GetChanges(dc, env, status string) ([]Change, error) {
+	where := make([]string, 0, 3)
+	args := make([]interface{}, 0, 3)
+	for _, c := range [][2]string{{"dc", dc}, {"env", env}, {"status", status}} {
+		if c[1] != "" {
+			where = append(where, fmt.Sprintf("`%s`=?", c[0]))
+			args = append(args, c[1])
+		}
+	}
+
+	session := s.engine.Select("`data`").Table(s.chtable)
+	if len(where) > 0 {
+		session = session.Where(strings.Join(where, " AND "), args...)
+	}
+	vs, err := session.Asc("`time`", "`id`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, len(vs))
+	for i, v := range vs {
+		if err = json.Unmarshal([]byte(v["data"]), &changes[i]); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// GetChange returns the change identified by id.
+func (s *sqlStore) GetChange(id string) (change Change, err error) {
+	vs, err := s.engine.Select("`data`").Table(s.chtable).Where("`id`=?",
+		id).QueryString()
+	if err != nil {
+		return
+	} else if len(vs) == 0 {
+		return change, ErrNotFound
+	}
+	err = json.Unmarshal([]byte(vs[0]["data"]), &change)
+	return
+}
+
+// UpdateChange updates the change only if the revision is not changed.
+func (s *sqlStore) UpdateChange(change Change) (Change, error) {
+	revision := change.Revision
+	change.Revision++
+	data, err := json.Marshal(change)
+	if err != nil {
+		return Change{}, err
+	}
+
+	q := "UPDATE `%s` SET `status`=?, `revision`=?, `data`=? WHERE `id`=? AND `revision`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.chtable), change.Status,
+		change.Revision, string(data), change.ID, revision)
+	if err != nil {
+		return Change{}, err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return Change{}, err
+	} else if n == 0 {
+		if _, err = s.GetChange(change.ID); err != nil {
+			return Change{}, err
+		}
+		return Change{}, ErrConflict
+	}
+	return change, nil
+}
+
+// AddSchedule inserts the schedule.
+func (s *sqlStore) AddSchedule(schedule Schedule) (Schedule, error) {
+	now := time.Now()
+	schedule.ID = newID(now)
+	schedule.Time = now.Unix()
+
+	q := "INSERT INTO `%s`(`id`,`dc`,`env`,`app`,`key`,`value`,`at`,`time`) VALUES(?,?,?,?,?,?,?,?)"
+	_, err := s.engine.Exec(fmt.Sprintf(q, s.sctable), schedule.ID,
+		schedule.Dc, schedule.Env, schedule.App, schedule.Key, schedule.Value,
+		schedule.At, schedule.Time)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// querySchedules returns the schedules matching the conditions.
+func (s *sqlStore) querySchedules(where string, args ...interface{}) (
+	[]Schedule, error) {
+	session := s.engine.Select("`id`,`dc`,`env`,`app`,`key`,`value`,`at`,`time`").
+		Table(s.sctable)
+	if where != "" {
+		session = session.Where(where, args...)
+	}
+	vs, err := session.Asc("`at`", "`id`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+	return parseSchedules(vs)
+}
+
+// parseSchedules returns the schedules from the queried rows.
+func parseSchedules(vs []map[string]string) (schedules []Schedule, err error) {
+	schedules = make([]Schedule, len(vs))
+	for i, v := range vs {
+		schedules[i] = Schedule{ID: v["id"], Dc: v["dc"], Env: v["env"],
+			App: v["app"], Key: v["key"], Value: v["value"]}
+		if schedules[i].At, err = types.ToInt64(v["at"]); err != nil {
+			return nil, err
+		}
+		if schedules[i].Time, err = types.ToInt64(v["time"]); err != nil {
+			return nil, err
+		}
+	}
+	return schedules, nil
+}
+
+// GetSchedules returns the schedules in the ascending order of the time.
+func (s *sqlStore) GetSchedules(dc, env, app string) ([]Schedule, error) {
+	where := make([]string, 0, 3)
+	args := make([]interface{}, 0, 3)
+	for _, c := range [][2]string{{"dc", dc}, {"env", env}, {"app", app}} {
+		if c[1] != "" {
+			where = append(where, fmt.Sprintf("`%s`=?", c[0]))
+			args = append(args, c[1])
+		}
+	}
+	return s.querySchedules(strings.Join(where, " AND "), args...)
+}
+
+// DeleteSchedule deletes the schedule, which succeeds only if the row
+// is deleted by this call.
+func (s *sqlStore) DeleteSchedule(id string) (Schedule, error) {
+	schedules, err := s.querySchedules("`id`=?", id)
+	if err != nil {
+		return Schedule{}, err
+	} else if len(schedules) == 0 {
+		return Schedule{}, ErrNotFound
+	}
+
+	q := "DELETE FROM `%s` WHERE `id`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.sctable), id)
+	if err != nil {
+		return Schedule{}, err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return Schedule{}, err
+	} else if n == 0 {
+		return Schedule{}, ErrNotFound
+	}
+	return schedules[0], nil
+}
+
+// ApplySchedule inserts the value of the schedule and deletes the schedule
+// in a transaction, which is claimed by the row deleted.
+func (s *sqlStore) ApplySchedule(id string) (schedule Schedule, err error) {
+	err = s.transact(func(session *xorm.Session) error {
+		vs, err := session.Select("`id`,`dc`,`env`,`app`,`key`,`value`,`at`,`time`").
+			Table(s.sctable).Where("`id`=?", id).QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) == 0 {
+			return ErrNotFound
+		}
+		schedules, err := parseSchedules(vs)
+		if err != nil {
+			return err
+		}
+		schedule = schedules[0]
+
+		q := "DELETE FROM `%s` WHERE `id`=?"
+		r, err := session.Exec(fmt.Sprintf(q, s.sctable), id)
+		if err != nil {
+			return err
+		} else if n, err := r.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrNotFound
+		}
+
+		now := time.Now().Unix()
+		q = "INSERT INTO `%s`(`dc`, `env`, `app`, `key`, `time`, `value`) VALUES(?, ?, ?, ?, ?, ?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.table), schedule.Dc, schedule.Env,
+			schedule.App, schedule.Key, now, schedule.Value)
+		if err != nil {
+			return err
+		}
+		return s.addEvent(session, schedule.Dc, schedule.Env, schedule.App,
+			schedule.Key, now, schedule.Value, false)
+	})
+	return
+}
+
+// AddCanary inserts the canary, the whole of which is saved as JSON.
+func (s *sqlStore) AddCanary(canary Canary) (Canary, error) {
+	canary.Time = time.Now().Unix()
+	data, err := json.Marshal(canary)
+	if err != nil {
+		return Canary{}, err
+	}
+
+	err = s.transact(func(session *xorm.Session) error {
+		where := "`dc`=? AND `env`=? AND `app`=? AND `key`=?"
+		vs, err := session.Select("`id`").Table(s.cntable).Where(where,
+			canary.Dc, canary.Env, canary.App, canary.Key).Limit(1).QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) > 0 {
+			return ErrExist
+		}
+
+		q := "INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`time`,`data`) VALUES(?,?,?,?,?,?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.cntable), canary.Dc, canary.Env,
+			canary.App, canary.Key, canary.Time, string(data))
+		return err
+	})
+	if err != nil {
+		return Canary{}, err
+	}
+	return canary, nil
+}
+
+// UpdateCanary updates the data of the canary.
+func (s *sqlStore) UpdateCanary(canary Canary) (Canary, error) {
+	canary.Time = time.Now().Unix()
+	data, err := json.Marshal(canary)
+	if err != nil {
+		return Canary{}, err
+	}
+
+	q := "UPDATE `%s` SET `time`=?, `data`=? WHERE `dc`=? AND `env`=? AND `app`=? AND `key`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.cntable), canary.Time,
+		string(data), canary.Dc, canary.Env, canary.App, canary.Key)
+	if err != nil {
+		return Canary{}, err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return Canary{}, err
+	} else if n == 0 {
+		// MySQL reports no affected rows if nothing is changed.
+		if _, err = s.GetCanary(canary.Dc, canary.Env, canary.App,
+			canary.Key); err != nil {
+			return Canary{}, err
+		}
+	}
+	return canary, nil
+}
+
+// GetCanaries returns the canaries of the app in the order of the key.
+func (s *sqlStore) GetCanaries(dc, env, app string) ([]Canary, error) {
+	vs, err := s.engine.Select("`data`").Table(s.cntable).Where(
+		"`dc`=? AND `env`=? AND `app`=?", dc, env, app).Asc("`key`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	canaries := make([]Canary, len(vs))
+	for i, v := range vs {
+		if err = json.Unmarshal([]byte(v["data"]), &canaries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return canaries, nil
+}
+
+// GetCanary returns the canary of the key.
+func (s *sqlStore) GetCanary(dc, env, app, key string) (canary Canary,
+	err error) {
+	vs, err := s.engine.Select("`data`").Table(s.cntable).Where(
+		"`dc`=? AND `env`=? AND `app`=? AND `key`=?", dc, env, app, key).
+		Limit(1).QueryString()
+	if err != nil {
+		return
+	} else if len(vs) == 0 {
+		return canary, ErrNotFound
+	}
+	err = json.Unmarshal([]byte(vs[0]["data"]), &canary)
+	return
+}
+
+// DeleteCanary deletes the canary of the key.
+func (s *sqlStore) DeleteCanary(dc, env, app, key string) error {
+	q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=? AND `key`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.cntable), dc, env, app, key)
+	if err != nil {
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetDependencies replaces the dependencies of the app in a transaction.
+func (s *sqlStore) SetDependencies(dc, env, app string,
+	namespaces []string) error {
+	return s.transact(func(session *xorm.Session) error {
+		q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=?"
+		_, err := session.Exec(fmt.Sprintf(q, s.dptable), dc, env, app)
+		if err != nil {
+			return err
+		}
+
+		q = "INSERT INTO `%s`(`dc`,`env`,`app`,`namespace`,`priority`) VALUES(?,?,?,?,?)"
+		for i, ns := range namespaces {
+			_, err = session.Exec(fmt.Sprintf(q, s.dptable), dc, env, app, ns, i)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetDependencies returns the namespaces in the order of the priority.
+func (s *sqlStore) GetDependencies(dc, env, app string) ([]string, error) {
+	vs, err := s.engine.Select("`namespace`").Table(s.dptable).Where(
+		"`dc`=? AND `env`=? AND `app`=?", dc, env, app).Asc("`priority`").
+		QueryString()
+	if err != nil || len(vs) == 0 {
+		return nil, err
+	}
+
+	namespaces := make([]string, len(vs))
+	for i, v := range vs {
+		namespaces[i] = v["namespace"]
+	}
+	return namespaces, nil
+}
+
+// GetDependents returns the apps depending on the namespace.
+func (s *sqlStore) GetDependents(dc, env, namespace string) ([]string,
+	error) {
+	vs, err := s.engine.Select("`app`").Table(s.dptable).Where(
+		"`dc`=? AND `env`=? AND `namespace`=?", dc, env, namespace).
+		Asc("`app`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	apps := make([]string, len(vs))
+	for i, v := range vs {
+		apps[i] = v["app"]
+	}
+	return apps, nil
+}
+
+// SetMetadata replaces the metadata of the key in a transaction, the whole
+// of which is saved as JSON.
+func (s *sqlStore) SetMetadata(metadata Metadata) (Metadata, error) {
+	metadata.Time = time.Now().Unix()
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	err = s.transact(func(session *xorm.Session) error {
+		q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=? AND `key`=?"
+		_, err := session.Exec(fmt.Sprintf(q, s.mdtable), metadata.Dc,
+			metadata.Env, metadata.App, metadata.Key)
+		if err != nil {
+			return err
+		}
+
+		q = "INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`time`,`data`) VALUES(?,?,?,?,?,?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.mdtable), metadata.Dc,
+			metadata.Env, metadata.App, metadata.Key, metadata.Time, string(data))
+		return err
+	})
+	if err != nil {
+		return Metadata{}, err
+	}
+	return metadata, nil
+}
+
+// GetMetadata returns the metadata of the key.
+func (s *sqlStore) GetMetadata(dc, env, app, key string) (metadata Metadata,
+	err error) {
+	vs, err := s.engine.Select("`data`").Table(s.mdtable).Where(
+		"`dc`=? AND `env`=? AND `app`=? AND `key`=?", dc, env, app, key).
+		Limit(1).QueryString()
+	if err != nil {
+		return
+	} else if len(vs) == 0 {
+		return metadata, ErrNotFound
+	}
+	err = json.Unmarshal([]byte(vs[0]["data"]), &metadata)
+	return
+}
+
+// GetAllMetadata returns the metadata of all the keys of the app.
+func (s *sqlStore) GetAllMetadata(dc, env, app string) (map[string]Metadata,
+	error) {
+	vs, err := s.engine.Select("`data`").Table(s.mdtable).Where(
+		"`dc`=? AND `env`=? AND `app`=?", dc, env, app).QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]Metadata, len(vs))
+	for _, v := range vs {
+		var md Metadata
+		if err = json.Unmarshal([]byte(v["data"]), &md); err != nil {
+			return nil, err
+		}
+		metadata[md.Key] = md
+	}
+	return metadata, nil
+}
+
+// DeleteMetadata deletes the metadata of the key.
+func (s *sqlStore) DeleteMetadata(dc, env, app, key string) error {
+	q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=? AND `key`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.mdtable), dc, env, app, key)
+	if err != nil {
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetDraft replaces the draft of the key in a transaction.
+func (s *sqlStore) SetDraft(dc, env, app, key, value string) error {
+	return s.transact(func(session *xorm.Session) error {
+		q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=? AND `key`=?"
+		_, err := session.Exec(fmt.Sprintf(q, s.drtable), dc, env, app, key)
+		if err != nil {
+			return err
+		}
+
+		q = "INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`time`,`value`) VALUES(?,?,?,?,?,?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.drtable), dc, env, app, key,
+			time.Now().Unix(), value)
+		return err
+	})
+}
+
+func (s *sqlStore) getDrafts(session *xorm.Session, dc, env, app string) (
+	[]Draft, error) {
+	vs, err := session.Select("`key`, `time`, `value`").Table(s.drtable).Where(
+		"`dc`=? AND `env`=? AND `app`=?", dc, env, app).Asc("`key`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := make([]Draft, len(vs))
+	for i, v := range vs {
+		t, err := types.ToInt64(v["time"])
+		if err != nil {
+			return nil, err
+		}
+		drafts[i] = Draft{Key: v["key"], Value: v["value"], Time: t}
+	}
+	return drafts, nil
+}
+
+// GetDrafts returns all the drafts of the app in the order of the key.
+func (s *sqlStore) GetDrafts(dc, env, app string) ([]Draft, error) {
+	session := s.engine.NewSession()
+	defer session.Close()
+	return s.getDrafts(session, dc, env, app)
+}
+
+// DeleteDrafts discards the draft of the key, or all the drafts of the app.
+func (s *sqlStore) DeleteDrafts(dc, env, app, key string) error {
+	where := "`dc`=? AND `env`=? AND `app`=?"
+	args := []interface{}{dc, env, app}
+	if key != "" {
+		where += " AND `key`=?"
+		args = append(args, key)
+	}
+
+	sql := fmt.Sprintf("DELETE FROM `%s` WHERE %s", s.drtable, where)
+	r, err := s.engine.Exec(sql, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PublishDrafts inserts the drafts into the config table, and deletes them,
+// in a transaction.
+func (s *sqlStore) PublishDrafts(dc, env, app string) (
+	published map[string]Version, err error) {
+	err = s.transact(func(session *xorm.Session) error {
+		drafts, err := s.getDrafts(session, dc, env, app)
+		if err != nil {
+			return err
+		} else if len(drafts) == 0 {
+			return ErrNotFound
+		}
+
+		now := time.Now().Unix()
+		published = make(map[string]Version, len(drafts))
+		q := fmt.Sprintf("INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`time`,`value`) VALUES(?,?,?,?,?,?)",
+			s.table)
+		for _, draft := range drafts {
+			_, err = session.Exec(q, dc, env, app, draft.Key, now, draft.Value)
+			if err != nil {
+				return err
+			}
+			err = s.addEvent(session, dc, env, app, draft.Key, now, draft.Value,
+				false)
+			if err != nil {
+				return err
+			}
+			published[draft.Key] = Version{Time: now, Value: draft.Value}
+		}
+
+		sql := fmt.Sprintf("DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=?",
+			s.drtable)
+		_, err = session.Exec(sql, dc, env, app)
+		return err
+	})
+	return
+}
+
+// CreateTag captures the latest version of each key into the versions
+// of a row of the tag table, as JSON, in a transaction.
+func (s *sqlStore) CreateTag(dc, env, app, name string) (tag Tag, err error) {
+	err = s.transact(func(session *xorm.Session) error {
+		where := "`dc`=? AND `env`=? AND `app`=? AND `name`=?"
+		vs, err := session.Select("`id`").Table(s.tgtable).Where(where, dc, env,
+			app, name).Limit(1).QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) > 0 {
+			return ErrExist
+		}
+
+		vs, err = session.Select("`key`, MAX(`time`) AS `time`").Table(
+			s.table).Where("`dc`=? AND `env`=? AND `app`=? AND `key`<>''", dc,
+			env, app).GroupBy("`key`").QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) == 0 {
+			return ErrNotFound
+		}
+
+		tag = Tag{Name: name, Time: time.Now().Unix(),
+			Versions: make(map[string]int64, len(vs))}
+		for _, v := range vs {
+			if tag.Versions[v["key"]], err = types.ToInt64(v["time"]); err != nil {
+				return err
+			}
+		}
+
+		data, err := json.Marshal(tag.Versions)
+		if err != nil {
+			return err
+		}
+		q := "INSERT INTO `%s`(`dc`,`env`,`app`,`name`,`time`,`versions`) VALUES(?,?,?,?,?,?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.tgtable), dc, env, app, name,
+			tag.Time, string(data))
+		return err
+	})
+	return
+}
+
+func (s *sqlStore) toTag(v map[string]string) (Tag, error) {
+	t, err := types.ToInt64(v["time"])
+	if err != nil {
+		return Tag{}, err
+	}
+	tag := Tag{Name: v["name"], Time: t}
+	err = json.Unmarshal([]byte(v["versions"]), &tag.Versions)
+	return tag, err
+}
+
+// GetTags returns all the tags of the app in the ascending order of the time.
+func (s *sqlStore) GetTags(dc, env, app string) ([]Tag, error) {
+	vs, err := s.engine.Select("`name`, `time`, `versions`").Table(s.tgtable).
+		Where("`dc`=? AND `env`=? AND `app`=?", dc, env, app).Asc("`time`",
+		"`name`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]Tag, len(vs))
+	for i, v := range vs {
+		if tags[i], err = s.toTag(v); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// GetTag returns the tag of the app.
+func (s *sqlStore) GetTag(dc, env, app, name string) (Tag, error) {
+	vs, err := s.engine.Select("`name`, `time`, `versions`").Table(s.tgtable).
+		Where("`dc`=? AND `env`=? AND `app`=? AND `name`=?", dc, env, app,
+			name).Limit(1).QueryString()
+	if err != nil {
+		return Tag{}, err
+	} else if len(vs) == 0 {
+		return Tag{}, ErrNotFound
+	}
+	return s.toTag(vs[0])
+}
+
+// DeleteTag deletes the tag of the app.
+func (s *sqlStore) DeleteTag(dc, env, app, name string) error {
+	q := "DELETE FROM `%s` WHERE `dc`=? AND `env`=? AND `app`=? AND `name`=?"
+	r, err := s.engine.Exec(fmt.Sprintf(q, s.tgtable), dc, env, app, name)
+	if err != nil {
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// getTrashTables returns the tables, the rows of which are moved into
+// the trash. The key is the name of the rows in the data of the trash.
+//
+// The tags, which have no key, are moved only with the whole app.
+func (s *sqlStore) getTrashTables(key string) map[string]string {
+	tables := map[string]string{
+		"config":    s.table,
+		"callback":  s.cbtable,
+		"result":    s.crtable,
+		"rollback":  s.rbtable,
+		"metadata":  s.mdtable,
+		"drafts":    s.drtable,
+		"canaries":  s.cntable,
+		"schedules": s.sctable,
+	}
+	if key == "" {
+		tables["tags"] = s.tgtable
+	}
+	return tables
+}
+
+// trashUniques is the unique columns of the rows in the data of the trash,
+// which must not exist when restoring the trash.
+var trashUniques = map[string][]string{
+	"drafts":   {"dc", "env", "app", "key"},
+	"canaries": {"dc", "env", "app", "key"},
+	"tags":     {"dc", "env", "app", "name"},
+}
+
+// TrashConfig moves the rows of the config in all the tables into the data
+// of a row of the trash table, as JSON, in a transaction.
+func (s *sqlStore) TrashConfig(dc, env, app, key string) (Trash, error) {
+	trash := newTrash(dc, env, app, key)
+	where := "`dc`=?"
+	args := []interface{}{trash.Dc}
+	for _, c := range [][2]string{{"env", trash.Env}, {"app", trash.App},
+		{"key", trash.Key}} {
+		if c[1] == "" {
+			break
+		}
+		where += fmt.Sprintf(" AND `%s`=?", c[0])
+		args = append(args, c[1])
+	}
+
+	err := s.transact(func(session *xorm.Session) error {
+		tables := s.getTrashTables(trash.Key)
+		data := make(map[string][]map[string]string, len(tables))
+		for name, table := range tables {
+			rows, err := session.Select("*").Table(table).Where(where,
+				args...).QueryString()
+			if err != nil {
+				return err
+			}
+			// Keep the id of the schedule, which is not auto-increment.
+			for _, row := range rows {
+				if name != "schedules" {
+					delete(row, "id")
+				}
+			}
+			data[name] = rows
+		}
+		if len(data["config"]) == 0 {
+			return ErrNotFound
+		}
+
+		for _, table := range tables {
+			sql := fmt.Sprintf("DELETE FROM `%s` WHERE %s", table, where)
+			if _, err := session.Exec(sql, args...); err != nil {
+				return err
+			}
+		}
+
+		bs, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		q := "INSERT INTO `%s`(`id`,`dc`,`env`,`app`,`key`,`time`,`data`) VALUES(?,?,?,?,?,?,?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.trtable), trash.ID, trash.Dc,
+			trash.Env, trash.App, trash.Key, trash.Time, string(bs))
+		if err != nil {
+			return err
+		}
+
+		return s.addEvent(session, trash.Dc, trash.Env, trash.App, trash.Key, 0,
+			"", true)
+	})
+	if err != nil {
+		return Trash{}, err
+	}
+	return trash, nil
+}
+
+func (s *sqlStore) toTrash(v map[string]string) (Trash, error) {
+	t, err := types.ToInt64(v["time"])
+	if err != nil {
+		return Trash{}, err
+	}
+	return Trash{ID: v["id"], Dc: v["dc"], Env: v["env"], App: v["app"],
+		Key: v["key"], Time: t}, nil
+}
+
+// GetTrashes returns all the trashes in the ascending order of the time.
+func (s *sqlStore) GetTrashes() ([]Trash, error) {
+	vs, err := s.engine.Select("`id`, `dc`, `env`, `app`, `key`, `time`").Table(
+		s.trtable).Asc("`time`", "`id`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	trashes := make([]Trash, len(vs))
+	for i, v := range vs {
+		if trashes[i], err = s.toTrash(v); err != nil {
+			return nil, err
+		}
+	}
+	return trashes, nil
+}
+
+// RestoreTrash inserts the rows in the data of the trash back into
+// the tables, and deletes the trash, in a transaction.
+func (s *sqlStore) RestoreTrash(id string) (trash Trash, err error) {
+	err = s.transact(func(session *xorm.Session) error {
+		vs, err := session.Select("*").Table(s.trtable).Where("`id`=?",
+			id).QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) == 0 {
+			return ErrNotFound
+		}
+		if trash, err = s.toTrash(vs[0]); err != nil {
+			return err
+		}
+
+		var data map[string][]map[string]string
+		if err = json.Unmarshal([]byte(vs[0]["data"]), &data); err != nil {
+			return err
+		}
+
+		// The placeholder of dc and env, the app of which is "", does not
+		// conflict, but is not restored again if it has existed.
+		where := "`dc`=? AND `env`=? AND `app`=? AND `key`=?"
+		existed := make(map[[4]string]bool, len(data["config"]))
+		for _, row := range data["config"] {
+			k := [4]string{row["dc"], row["env"], row["app"], row["key"]}
+			if _, ok := existed[k]; ok {
+				continue
+			}
+
+			rs, err := session.Select("`id`").Table(s.table).Where(where, k[0],
+				k[1], k[2], k[3]).Limit(1).QueryString()
+			if err != nil {
+				return err
+			} else if len(rs) > 0 && k[2] != "" {
+				return ErrExist
+			}
+			existed[k] = len(rs) > 0
+		}
+
+		// The draft, the canary and the tag created again after trashed
+		// conflict with the restored ones.
+		tables := s.getTrashTables(trash.Key)
+		for name, columns := range trashUniques {
+			for _, row := range data[name] {
+				w := make([]string, len(columns))
+				vs := make([]interface{}, len(columns))
+				for i, column := range columns {
+					w[i] = fmt.Sprintf("`%s`=?", column)
+					vs[i] = row[column]
+				}
+				rs, err := session.Select("`id`").Table(tables[name]).Where(
+					strings.Join(w, " AND "), vs...).Limit(1).QueryString()
+				if err != nil {
+					return err
+				} else if len(rs) > 0 {
+					return ErrExist
+				}
+			}
+		}
+
+		for name, table := range tables {
+			for _, row := range data[name] {
+				if name == "config" && existed[[4]string{row["dc"], row["env"],
+					row["app"], row["key"]}] {
+					continue
+				} else if name == "metadata" {
+					// Replace the metadata left by the key deleted before.
+					sql := fmt.Sprintf("DELETE FROM `%s` WHERE %s", table, where)
+					if _, err = session.Exec(sql, row["dc"], row["env"],
+						row["app"], row["key"]); err != nil {
+						return err
+					}
+				}
+
+				columns := make([]string, 0, len(row))
+				for column := range row {
+					columns = append(columns, column)
+				}
+				sort.Strings(columns)
+
+				values := make([]interface{}, len(columns))
+				for i, column := range columns {
+					values[i] = row[column]
+				}
+				sql := fmt.Sprintf("INSERT INTO `%s`(`%s`) VALUES(?%s)", table,
+					strings.Join(columns, "`,`"),
+					strings.Repeat(",?", len(columns)-1))
+				if _, err = session.Exec(sql, values...); err != nil {
+					return err
+				}
+			}
+		}
+
+		sql := fmt.Sprintf("DELETE FROM `%s` WHERE `id`=?", s.trtable)
+		if _, err = session.Exec(sql, id); err != nil {
+			return err
+		}
+
+		// Record the latest value of each restored key.
+		latest := make(map[[4]string]int64, len(existed))
+		values := make(map[[4]string]string, len(existed))
+		for _, row := range data["config"] {
+			k := [4]string{row["dc"], row["env"], row["app"], row["key"]}
+			if k[3] == "" {
+				continue
+			}
+			t, err := types.ToInt64(row["time"])
+			if err != nil {
+				return err
+			}
+			if t >= latest[k] {
+				latest[k] = t
+				values[k] = row["value"]
+			}
+		}
+		for k, t := range latest {
+			if err = s.addEvent(session, k[0], k[1], k[2], k[3], t, values[k],
+				false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// PurgeTrash deletes the trash permanently.
+func (s *sqlStore) PurgeTrash(id string) error {
+	sql := fmt.Sprintf("DELETE FROM `%s` WHERE `id`=?", s.trtable)
+	r, err := s.engine.Exec(sql, id)
+	if err != nil {
+		return err
+	}
+	if n, err := r.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // SetKeyValue sets the key-value in dc, evn and app with a new timestamp.
 func (s *sqlStore) SetKeyValue(dc, env, app, key, value string) error {
+	now := time.Now().Unix()
 	sql := "INSERT INTO `%s`(`dc`, `env`, `app`, `key`, `time`, `value`) VALUES(?, ?, ?, ?, ?, ?)"
 	sql = fmt.Sprintf(sql, s.table)
-	_, err := s.engine.Exec(sql, dc, env, app, key, time.Now().Unix(), value)
-	return err
+	return s.transact(func(session *xorm.Session) error {
+		if _, err := session.Exec(sql, dc, env, app, key, now, value); err != nil {
+			return err
+		}
+		return s.addEvent(session, dc, env, app, key, now, value, false)
+	})
+}
+
+// SetKeyValues sets the values of the key in dc, evn and app with the given
+// versions.
+func (s *sqlStore) SetKeyValues(dc, env, app, key string,
+	values map[int64]string) error {
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	sql := "INSERT INTO `%s`(`dc`, `env`, `app`, `key`, `time`, `value`) VALUES(?, ?, ?, ?, ?, ?)"
+	sql = fmt.Sprintf(sql, s.table)
+	return s.transact(func(session *xorm.Session) error {
+		var latest int64
+		for t, v := range values {
+			if _, err := session.Exec(sql, dc, env, app, key, t, v); err != nil {
+				return err
+			}
+			if t > latest {
+				latest = t
+			}
+		}
+		return s.addEvent(session, dc, env, app, key, latest, values[latest],
+			false)
+	})
 }
 
 // GetAllApps returns the names of all apps in dc and env.
@@ -372,6 +1482,164 @@ func (s *sqlStore) GetAllValues(dc, env, app, key string, page, number, from,
 	return total, values, nil
 }
 
+// GetConfigAsOf returns the newest versions at or before at of all the keys
+// by one query, which joins the newest time at or before at of each key.
+func (s *sqlStore) GetConfigAsOf(dc, env, app, key string, at int64) (
+	map[string]Version, error) {
+	where := "`dc`=? AND `env`=? AND `app`=? AND `key`<>'' AND `time`<=?"
+	args := []interface{}{dc, env, app, at}
+	if key != "" {
+		where += " AND `key`=?"
+		args = append(args, key)
+	}
+
+	q := "SELECT c.`key`, c.`time`, c.`value` FROM `%s` c JOIN (SELECT `key`, MAX(`time`) AS `time` FROM `%s` WHERE %s GROUP BY `key`) m ON c.`key`=m.`key` AND c.`time`=m.`time` WHERE c.`dc`=? AND c.`env`=? AND c.`app`=?"
+	args = append(args, dc, env, app)
+	vs, err := s.engine.SQL(fmt.Sprintf(q, s.table, s.table, where),
+		args...).QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]Version, len(vs))
+	for _, v := range vs {
+		t, err := types.ToInt64(v["time"])
+		if err != nil {
+			return nil, err
+		}
+		result[v["key"]] = Version{Time: t, Value: v["value"]}
+	}
+	return result, nil
+}
+
+// RollbackKey re-publishes the value of the key at _time as a new version,
+// and records the rollback.
+func (s *sqlStore) RollbackKey(dc, env, app, key string, _time int64) (
+	version int64, err error) {
+
+	version = time.Now().Unix()
+	err = s.transact(func(session *xorm.Session) error {
+		where := "`dc`=? AND `env`=? AND `app`=? AND `key`=? AND `time`=?"
+		vs, err := session.Select("`value`").Table(s.table).Where(where, dc, env,
+			app, key, _time).Limit(1).QueryString()
+		if err != nil {
+			return err
+		} else if len(vs) == 0 {
+			return ErrNotFound
+		}
+		value := vs[0]["value"]
+
+		q := "INSERT INTO `%s`(`dc`, `env`, `app`, `key`, `time`, `value`) VALUES(?, ?, ?, ?, ?, ?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.table), dc, env, app, key, version,
+			value)
+		if err != nil {
+			return err
+		}
+
+		q = "INSERT INTO `%s`(`dc`, `env`, `app`, `key`, `time`, `target`) VALUES(?, ?, ?, ?, ?, ?)"
+		_, err = session.Exec(fmt.Sprintf(q, s.rbtable), dc, env, app, key,
+			version, _time)
+		if err != nil {
+			return err
+		}
+
+		return s.addEvent(session, dc, env, app, key, version, value, false)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	return
+}
+
+// GetRollbacks returns the records of the rollback of the key
+// in the descending order of the time.
+func (s *sqlStore) GetRollbacks(dc, env, app, key string) ([]Rollback, error) {
+	where := "`dc`=? AND `env`=? AND `app`=? AND `key`=?"
+	vs, err := s.engine.Select("`time`, `target`").Table(s.rbtable).Where(
+		where, dc, env, app, key).Desc("`id`").QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Rollback, len(vs))
+	for i, v := range vs {
+		if result[i].Time, err = types.ToInt64(v["time"]); err != nil {
+			return nil, err
+		}
+		if result[i].Target, err = types.ToInt64(v["target"]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// addEvent records a change event in the transaction session.
+func (s *sqlStore) addEvent(session *xorm.Session, dc, env, app, key string,
+	_time int64, value string, deleted bool) error {
+
+	q := "INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`time`,`value`,`deleted`) VALUES(?,?,?,?,?,?,?)"
+	r, err := session.Exec(fmt.Sprintf(q, s.evtable), dc, env, app, key, _time,
+		value, deleted)
+	if err != nil {
+		return err
+	}
+
+	// Discard the old events every 100 events.
+	if id, err := r.LastInsertId(); err == nil && id%100 == 0 {
+		q = fmt.Sprintf("DELETE FROM `%s` WHERE `id`<=?", s.evtable)
+		_, err = session.Exec(q, id-maxEvents)
+		return err
+	}
+	return nil
+}
+
+// GetEvents returns the change events, the id of which is greater than
+// since, in the ascending order of the id.
+func (s *sqlStore) GetEvents(since, number int64) ([]Event, error) {
+	columns := "`id`, `dc`, `env`, `app`, `key`, `time`, `value`, `deleted`"
+	session := s.engine.Select(columns).Table(s.evtable).Where("`id`>?",
+		since).Asc("`id`")
+	if number > 0 {
+		session = session.Limit(int(number))
+	}
+
+	vs, err := session.QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, len(vs))
+	for i, v := range vs {
+		if events[i].ID, err = types.ToInt64(v["id"]); err != nil {
+			return nil, err
+		}
+		if events[i].Time, err = types.ToInt64(v["time"]); err != nil {
+			return nil, err
+		}
+		if events[i].Deleted, err = types.ToBool(v["deleted"]); err != nil {
+			return nil, err
+		}
+		events[i].Dc = v["dc"]
+		events[i].Env = v["env"]
+		events[i].App = v["app"]
+		events[i].Key = v["key"]
+		events[i].Value = v["value"]
+	}
+	return events, nil
+}
+
+// GetLastEventID returns the id of the latest change event.
+func (s *sqlStore) GetLastEventID() (int64, error) {
+	vs, err := s.engine.Select("MAX(`id`) AS `id`").Table(s.evtable).QueryString()
+	if err != nil {
+		return 0, err
+	} else if len(vs) == 0 || vs[0]["id"] == "" {
+		return 0, nil
+	}
+	return types.ToInt64(vs[0]["id"])
+}
+
 func (s *sqlStore) AddCallback(dc, env, app, key, id, callback string) error {
 	q := "INSERT INTO `%s`(`dc`,`env`,`app`,`key`,`cbid`,`callback`)VALUES(?,?,?,?,?,?)"
 	sql := fmt.Sprintf(q, s.cbtable)
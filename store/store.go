@@ -1,13 +1,22 @@
 package store
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 )
 
 var (
 	backends = make(map[string]Store, 2)
 )
 
+// maxEvents is the maximum number of the change events kept by the backend
+// store. The older events will be discarded.
+const maxEvents = 10000
+
 var (
 	// ErrNotFound is returned when the record does not exist.
 	ErrNotFound = fmt.Errorf("not found")
@@ -17,6 +26,9 @@ var (
 
 	// ErrNoDcAndEnv is returned when there is no dc and evn.
 	ErrNoDcAndEnv = fmt.Errorf("no dc and env")
+
+	// ErrConflict is returned when the record has been changed by others.
+	ErrConflict = fmt.Errorf("has been changed")
 )
 
 // RegisterStore registers a backend store.
@@ -70,6 +82,238 @@ func GetInt64Page(result []int64, page, number int64) []int64 {
 	return result[start:end]
 }
 
+// Event is the change event of the config, which is recorded by the backend
+// store when setting or deleting the config.
+type Event struct {
+	// ID is the identifier of the event, which is unique and increasing
+	// in the backend store.
+	ID int64 `json:"id"`
+
+	// Dc, Env, App and Key are the config to be changed. For deleting the whole
+	// dc, env or app, the rest are "".
+	Dc  string `json:"dc"`
+	Env string `json:"env"`
+	App string `json:"app"`
+	Key string `json:"key"`
+
+	// Time is the version of the value to be set or deleted. If deleting
+	// the whole key, dc, env or app, it's 0.
+	Time  int64  `json:"time"`
+	Value string `json:"value,omitempty"`
+
+	// Deleted is true if the config is deleted, or false if set.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// Version is a version of the value of a key.
+type Version struct {
+	// Time is the version, that's, the unixstamp time when the value is set.
+	Time  int64  `json:"time"`
+	Value string `json:"value"`
+}
+
+// Draft is the pending value of a key, which is not visible to the app
+// until published.
+type Draft struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// Time is the unixstamp time when the draft was saved.
+	Time int64 `json:"time"`
+}
+
+// Tag is the named release of an app, which pins the versions of its keys.
+type Tag struct {
+	Name string `json:"name"`
+
+	// Time is the unixstamp time when the tag was created.
+	Time int64 `json:"time"`
+
+	// Versions is the versions of the keys. The key is the name of the key.
+	Versions map[string]int64 `json:"versions"`
+}
+
+// Rollback is the record of rolling back a key to a previous version.
+type Rollback struct {
+	// Time is the new version, which is set by the rollback.
+	Time int64 `json:"time"`
+
+	// Target is the previous version, the value of which is re-published.
+	Target int64 `json:"target"`
+}
+
+// Trash is the config deleted softly, which can be restored or purged.
+type Trash struct {
+	// ID is the unique identifier of the trash.
+	ID string `json:"id"`
+
+	// Dc, Env, App and Key are the deleted config. For the whole dc, env
+	// or app, the rest are "".
+	Dc  string `json:"dc"`
+	Env string `json:"env,omitempty"`
+	App string `json:"app,omitempty"`
+	Key string `json:"key,omitempty"`
+
+	// Time is the unixstamp time when the config was deleted.
+	Time int64 `json:"time"`
+}
+
+// newTrash returns a new trash of the config, the id of which is generated
+// by the current time.
+func newTrash(dc, env, app, key string) Trash {
+	// Clear the rest if deleting the whole dc or env.
+	if env == "" {
+		app, key = "", ""
+	} else if app == "" {
+		key = ""
+	}
+
+	now := time.Now()
+	return Trash{ID: newID(now), Dc: dc, Env: env, App: app, Key: key,
+		Time: now.Unix()}
+}
+
+// moveData returns the JSON data of the record of a key, such as a canary
+// or metadata, with the fields app and key replaced if they exist. The other
+// fields are kept as they are.
+func moveData(data []byte, app, key string) ([]byte, error) {
+	var v map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if _, ok := v["app"]; ok {
+		v["app"] = app
+	}
+	if _, ok := v["key"]; ok {
+		v["key"] = key
+	}
+	return json.Marshal(v)
+}
+
+// sortSchedules sorts the schedules in the ascending order of the scheduled
+// time, then the ID.
+func sortSchedules(schedules []Schedule) {
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].At == schedules[j].At {
+			return schedules[i].ID < schedules[j].ID
+		}
+		return schedules[i].At < schedules[j].At
+	})
+}
+
+// newID returns a new unique identifier generated by the time.
+func newID(now time.Time) string {
+	return strconv.FormatInt(now.UnixNano(), 10)
+}
+
+// The status of the change.
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+// Change is the proposed change of the value of a key, which needs
+// the approval before set.
+type Change struct {
+	ID    string `json:"id"`
+	Dc    string `json:"dc"`
+	Env   string `json:"env"`
+	App   string `json:"app"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// Base is the latest version of the key when proposed, or 0 if the key
+	// did not exist.
+	Base int64 `json:"base"`
+
+	// Requester is the identity proposing the change, and Time is
+	// the unixstamp time when proposed.
+	Requester string `json:"requester"`
+	Time      int64  `json:"time"`
+
+	// Status is one of ChangePending, ChangeApproved and ChangeRejected.
+	Status    string   `json:"status"`
+	Approvers []string `json:"approvers"`
+
+	// Reviewer and Reason are the identity rejecting the change and why.
+	Reviewer string `json:"reviewer,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+
+	// Version is the version of the value set when approved.
+	Version int64 `json:"version,omitempty"`
+
+	// Revision is increased by one each time the change is updated,
+	// which is used to update it only if not changed by others.
+	Revision int64 `json:"revision"`
+}
+
+// Schedule is the value of a key, which is set at the scheduled time.
+type Schedule struct {
+	ID    string `json:"id"`
+	Dc    string `json:"dc"`
+	Env   string `json:"env"`
+	App   string `json:"app"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// At is the unixstamp time when the value is set, and Time is
+	// the unixstamp time when scheduled.
+	At   int64 `json:"at"`
+	Time int64 `json:"time"`
+}
+
+// Canary is the candidate value of a key, which is served only to the clients
+// matching the rules instead of the stable value.
+type Canary struct {
+	Dc    string `json:"dc"`
+	Env   string `json:"env"`
+	App   string `json:"app"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// IPs is the IPs or CIDRs of the matching clients, and Instances is
+	// the instance IDs of the matching clients.
+	IPs       []string `json:"ips"`
+	Instances []string `json:"instances"`
+
+	// Percent is the percentage of the instance IDs of the matching clients,
+	// which is between 0 and 100.
+	Percent int `json:"percent"`
+
+	// Time is the unixstamp time when the canary is updated lastly.
+	Time int64 `json:"time"`
+}
+
+// Metadata is the metadata of a key, which describes it.
+type Metadata struct {
+	Dc  string `json:"dc"`
+	Env string `json:"env"`
+	App string `json:"app"`
+	Key string `json:"key"`
+
+	// Description is what the key is used for, such as the unit of the value.
+	Description string `json:"description"`
+
+	// Owner is the team owning the key.
+	Owner string `json:"owner"`
+
+	// Labels is the free-form labels of the key.
+	Labels []string `json:"labels"`
+
+	// ContentType is the MIME type of the value, such as "application/json".
+	ContentType string `json:"content_type"`
+
+	// Sensitive is true if the value is secret, which should not be shown.
+	Sensitive bool `json:"sensitive"`
+
+	// Time is the unixstamp time when the metadata is updated lastly.
+	Time int64 `json:"time"`
+}
+
 // Store is the interface of the backend store.
 type Store interface {
 	Init(conf string) error
@@ -78,7 +322,9 @@ type Store interface {
 	//
 	// If the time is 0 or negative, it should return the latest value.
 	// Or it should return the value at the provided time.
-	AppGetConfig(dc, env, app, key string, _time int64) (v string, err error)
+	//
+	// version is the time when the returned value was set.
+	AppGetConfig(dc, env, app, key string, _time int64) (v string, version int64, err error)
 
 	// CreateDcAndEnv creates the new dc and env.
 	//
@@ -94,19 +340,42 @@ type Store interface {
 	//   4. If key is "", it should delete the whole app.
 	//   5. If _time is 0 or negative, it should delete the whole key.
 	//
-	// Notice: you can consider them as "/dc/env/app/key/_time".
+	// Notice: you can consider them as "/dc/env/app/key/_time". Deleting
+	// the whole key deletes its records of the rollback, its metadata, draft,
+	// canary and schedules, too, and deleting the whole app deletes its tags.
 	DeleteConfig(dc, env, app, key string, _time int64) error
 
 	// GetAllDcAndEnvs returns all dc and env. The key is dc, and the value is
 	// the all envs in the dc.
 	GetAllDcAndEnvs() (map[string][]string, error)
 
+	// MoveConfig moves the key of app to toKey of toApp in dc and env,
+	// together with all its versions, callbacks, callback results, records
+	// of the rollback, draft, canary, metadata and schedules. If key is "",
+	// it moves the whole app to toApp, and toKey is ignored.
+	//
+	// If the source does not exist, it returns ErrNotFound. If the target,
+	// or its draft, canary or metadata, has existed, it returns ErrExist.
+	// The implementation should move them atomically if the backend store
+	// allows it.
+	//
+	// Notice: the implementation must record the change events to delete
+	// the source and to set the latest value of each moved key.
+	MoveConfig(dc, env, app, key, toApp, toKey string) error
+
 	// SetKeyValue sets the key-value in dc, evn and app.
 	//
 	// If the key has not existed, it will create it; Or append it with a new
 	// timestamp.
 	SetKeyValue(dc, env, app, key, value string) error
 
+	// SetKeyValues sets the values of the key in dc, evn and app with the given
+	// versions, which is used to copy the history of the key.
+	//
+	// Notice: the implementation only needs to record the change event
+	// of the latest value.
+	SetKeyValues(dc, env, app, key string, values map[int64]string) error
+
 	// GetAllApps returns the names of all apps in dc and env.
 	//
 	// If search is not "", it will return those apps the name of which contains
@@ -130,6 +399,232 @@ type Store interface {
 	// from and to is the start and end time to filte the values.
 	GetAllValues(dc, env, app, key string, page, number, from, to int64) (int64, map[int64]string, error)
 
+	// GetConfigAsOf returns the newest versions at or before the unixstamp
+	// time at of all the keys in dc, env and app. The key of the result is
+	// the name of the key.
+	//
+	// If key is not "", only return that key. The keys, which have no version
+	// at or before at, are excluded. If no key is found, return an empty map.
+	GetConfigAsOf(dc, env, app, key string, at int64) (map[string]Version, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Change Event
+
+	// GetEvents returns the change events, the id of which is greater than
+	// since, in the ascending order of the id.
+	//
+	// number is the maximum number of the returned events.
+	//
+	// Notice: when SetKeyValue or DeleteConfig succeeds, the implementation
+	// must record the change event, which can be read by all the instances
+	// sharing the same store. The implementation may discard the old events.
+	// The ids of the concurrent events may become visible out of order,
+	// which is tolerated by the caller.
+	GetEvents(since, number int64) ([]Event, error)
+
+	// GetLastEventID returns the id of the latest change event.
+	//
+	// If there is no event, it returns 0.
+	GetLastEventID() (int64, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Rollback
+
+	// RollbackKey re-publishes the value of the key at _time as a new version,
+	// which is returned, and records the rollback.
+	//
+	// If the value at _time does not exist, it returns ErrNotFound.
+	//
+	// Notice: the implementation must record the change event like SetKeyValue,
+	// and delete the records of the rollback when deleting the whole key.
+	RollbackKey(dc, env, app, key string, _time int64) (version int64, err error)
+
+	// GetRollbacks returns the records of the rollback of the key
+	// in the descending order of the time.
+	GetRollbacks(dc, env, app, key string) ([]Rollback, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Change Request
+
+	// AddChange adds the change, which is pending, and returns it with
+	// the generated ID, Time and Revision.
+	AddChange(change Change) (Change, error)
+
+	// GetChanges returns the changes in dc and env with the status in
+	// the ascending order of the time. If any of them is "", it matches all.
+	GetChanges(dc, env, status string) ([]Change, error)
+
+	// GetChange returns the change identified by id. If not exist,
+	// it returns ErrNotFound.
+	GetChange(id string) (Change, error)
+
+	// UpdateChange updates the change only if its revision is the same as
+	// the stored one, and returns it with the increased revision.
+	//
+	// If the change does not exist, it returns ErrNotFound. If the stored
+	// revision has been changed by others, it returns ErrConflict.
+	UpdateChange(change Change) (Change, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Scheduled Change
+
+	// AddSchedule adds the schedule, and returns it with the generated ID
+	// and Time.
+	AddSchedule(schedule Schedule) (Schedule, error)
+
+	// GetSchedules returns the schedules of the app in dc and env in
+	// the ascending order of the scheduled time. If any of them is "",
+	// it matches all.
+	GetSchedules(dc, env, app string) ([]Schedule, error)
+
+	// DeleteSchedule deletes the schedule identified by id and returns it.
+	//
+	// If the schedule does not exist, it returns ErrNotFound. Among
+	// the concurrent callers, even from many instances, only one succeeds.
+	DeleteSchedule(id string) (Schedule, error)
+
+	// ApplySchedule sets the value of the schedule identified by id as a new
+	// version, and deletes the schedule, both in a transaction. Among
+	// the concurrent callers, even from many instances, only one succeeds,
+	// so the schedule is applied exactly once. It returns the schedule.
+	//
+	// If the schedule does not exist, that's, it has been applied or deleted,
+	// it returns ErrNotFound. If failed, the schedule is kept to be retried.
+	ApplySchedule(id string) (Schedule, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Canary
+
+	// AddCanary adds the canary of the key, and returns it with the Time.
+	// If the key has had a canary, it returns ErrExist.
+	AddCanary(canary Canary) (Canary, error)
+
+	// UpdateCanary replaces the canary of the key, and returns it with
+	// the Time. If the key has no canary, it returns ErrNotFound.
+	UpdateCanary(canary Canary) (Canary, error)
+
+	// GetCanaries returns the canaries of the app in the order of the key.
+	GetCanaries(dc, env, app string) ([]Canary, error)
+
+	// GetCanary returns the canary of the key. If not exist, it returns
+	// ErrNotFound.
+	GetCanary(dc, env, app, key string) (Canary, error)
+
+	// DeleteCanary deletes the canary of the key. If not exist, it returns
+	// ErrNotFound.
+	DeleteCanary(dc, env, app, key string) error
+
+	///////////////////////////////////////////////////////////////////////////
+	// Namespace Dependency
+
+	// SetDependencies replaces the namespaces, on which the app in dc and env
+	// depends, in the order of the priority. If namespaces is empty, delete
+	// the dependencies.
+	SetDependencies(dc, env, app string, namespaces []string) error
+
+	// GetDependencies returns the namespaces, on which the app depends,
+	// in the order of the priority. If none, it returns nil.
+	GetDependencies(dc, env, app string) ([]string, error)
+
+	// GetDependents returns the apps in dc and env, which depend on
+	// the namespace, in the order of the name.
+	GetDependents(dc, env, namespace string) ([]string, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Key Metadata
+
+	// SetMetadata replaces the metadata of the key, and returns it with
+	// the Time.
+	SetMetadata(metadata Metadata) (Metadata, error)
+
+	// GetMetadata returns the metadata of the key. If not exist, it returns
+	// ErrNotFound.
+	GetMetadata(dc, env, app, key string) (Metadata, error)
+
+	// GetAllMetadata returns the metadata of all the keys of the app.
+	// The key of the result is the key.
+	GetAllMetadata(dc, env, app string) (map[string]Metadata, error)
+
+	// DeleteMetadata deletes the metadata of the key. If not exist, it returns
+	// ErrNotFound.
+	DeleteMetadata(dc, env, app, key string) error
+
+	///////////////////////////////////////////////////////////////////////////
+	// Draft
+
+	// SetDraft saves the value of the key in dc, env and app as a draft,
+	// which replaces the previous draft of the key.
+	SetDraft(dc, env, app, key, value string) error
+
+	// GetDrafts returns all the drafts of the app in the order of the key.
+	GetDrafts(dc, env, app string) ([]Draft, error)
+
+	// DeleteDrafts discards the draft of the key, or all the drafts of the app
+	// if key is "". If no draft is discarded, it returns ErrNotFound.
+	DeleteDrafts(dc, env, app, key string) error
+
+	// PublishDrafts sets all the drafts of the app as the values of the keys
+	// atomically, the versions of which are the same, then discards them.
+	// The key of the result is the name of the key.
+	//
+	// If the app has no drafts, it returns ErrNotFound.
+	//
+	// Notice: the implementation must record the change event for each key
+	// like SetKeyValue.
+	PublishDrafts(dc, env, app string) (map[string]Version, error)
+
+	///////////////////////////////////////////////////////////////////////////
+	// Tag
+
+	// CreateTag creates a tag named name of the app in dc and env, which
+	// captures the latest version of every key of the app.
+	//
+	// The tag is immutable. If it has existed, it returns ErrExist. If the app
+	// has no keys, it returns ErrNotFound.
+	CreateTag(dc, env, app, name string) (Tag, error)
+
+	// GetTags returns all the tags of the app in the ascending order
+	// of the time.
+	GetTags(dc, env, app string) ([]Tag, error)
+
+	// GetTag returns the tag of the app. If not exist, it returns ErrNotFound.
+	GetTag(dc, env, app, name string) (Tag, error)
+
+	// DeleteTag deletes the tag of the app. If not exist, it returns ErrNotFound.
+	DeleteTag(dc, env, app, name string) error
+
+	///////////////////////////////////////////////////////////////////////////
+	// Trash
+
+	// TrashConfig deletes the config softly, that's, moves the whole dc, env,
+	// app or key into the trash, together with all the versions, callbacks,
+	// callback results, records of the rollback, metadata, drafts, canaries
+	// and schedules, and the tags of the whole app.
+	//
+	// The arguments are the same as DeleteConfig, but no _time. If the config
+	// does not exist, it returns ErrNotFound.
+	//
+	// Notice: the implementation must record the change event like DeleteConfig.
+	TrashConfig(dc, env, app, key string) (Trash, error)
+
+	// GetTrashes returns all the trashes in the ascending order of the time.
+	GetTrashes() ([]Trash, error)
+
+	// RestoreTrash restores the config in the trash identified by id,
+	// and removes the trash.
+	//
+	// If the trash does not exist, it returns ErrNotFound. If any key in it,
+	// or its draft, canary or tag, has existed, it returns ErrExist.
+	//
+	// Notice: the implementation must record the change event to set
+	// the latest value of each restored key.
+	RestoreTrash(id string) (Trash, error)
+
+	// PurgeTrash deletes the trash identified by id permanently.
+	//
+	// If the trash does not exist, it returns ErrNotFound.
+	PurgeTrash(id string) error
+
 	///////////////////////////////////////////////////////////////////////////
 	// Callback Notification
 
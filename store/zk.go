@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/samuel/go-zookeeper/zk"
@@ -91,6 +93,86 @@ func (z *zkStore) cbResultPath(f string, args ...interface{}) string {
 	return "/cbresult" + path
 }
 
+func (z *zkStore) eventPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/event%s", z.root, path)
+	}
+	return "/event" + path
+}
+
+func (z *zkStore) rollbackPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/rollback%s", z.root, path)
+	}
+	return "/rollback" + path
+}
+
+func (z *zkStore) trashPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/trash%s", z.root, path)
+	}
+	return "/trash" + path
+}
+
+func (z *zkStore) tagPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/tag%s", z.root, path)
+	}
+	return "/tag" + path
+}
+
+func (z *zkStore) draftPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/draft%s", z.root, path)
+	}
+	return "/draft" + path
+}
+
+func (z *zkStore) changePath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/change%s", z.root, path)
+	}
+	return "/change" + path
+}
+
+func (z *zkStore) schedulePath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/schedule%s", z.root, path)
+	}
+	return "/schedule" + path
+}
+
+func (z *zkStore) canaryPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/canary%s", z.root, path)
+	}
+	return "/canary" + path
+}
+
+func (z *zkStore) dependencyPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/dependency%s", z.root, path)
+	}
+	return "/dependency" + path
+}
+
+func (z *zkStore) metadataPath(f string, args ...interface{}) string {
+	path := fmt.Sprintf(f, args...)
+	if z.root != "/" {
+		return fmt.Sprintf("%s/metadata%s", z.root, path)
+	}
+	return "/metadata" + path
+}
+
 func (z *zkStore) Init(conf string) (err error) {
 	var adds []string
 	var timeout = 3
@@ -147,104 +229,1333 @@ func (z *zkStore) Init(conf string) (err error) {
 	if err = z.ensurePath(z.cbPath("")); err != nil {
 		return
 	}
-	err = z.ensurePath(z.cbResultPath(""))
+	if err = z.ensurePath(z.cbResultPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.eventPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.rollbackPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.trashPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.tagPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.draftPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.changePath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.schedulePath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.canaryPath("")); err != nil {
+		return
+	}
+	if err = z.ensurePath(z.dependencyPath("")); err != nil {
+		return
+	}
+	err = z.ensurePath(z.metadataPath(""))
+
+	return
+}
+
+// AppGetConfig is used by the app to get the value of the key in APP.
+//
+// If the time is 0 or negative, it should return the latest value.
+// Or it should return the value at the provided time.
+func (z *zkStore) AppGetConfig(dc, env, app, key string, _time int64) (v string,
+	version int64, err error) {
+
+	if _time > 0 {
+		path := z.path("/%s/%s/%s/%s/%d", dc, env, app, key, _time)
+		data, _, err := z.zk.Get(path)
+		switch err {
+		case nil:
+			return string(data), _time, nil
+		case zk.ErrNoNode:
+			return "", 0, ErrNotFound
+		default:
+			return "", 0, err
+		}
+	}
+
+	// Get all the childrens, that's the timestamps.
+	path := z.path("/%s/%s/%s/%s", dc, env, app, key)
+	cs, _, err := z.zk.Children(path)
+	switch err {
+	case nil:
+	case zk.ErrNoNode:
+		return "", 0, ErrNotFound
+	default:
+		return
+	}
+
+	if len(cs) == 0 {
+		return "", 0, ErrNotFound
+	}
+
+	// Get the lastest timestamp
+	sort.Sort(sort.Reverse(sort.StringSlice(cs)))
+	path = fmt.Sprintf("%s/%s", path, cs[0])
+	if version, err = types.ToInt64(cs[0]); err != nil {
+		return
+	}
+
+	// Get the data
+	data, _, err := z.zk.Get(path)
+	switch err {
+	case nil:
+		return string(data), version, nil
+	case zk.ErrNoNode:
+		return "", 0, ErrNotFound
+	default:
+		return
+	}
+}
+
+// CreateDcAndEnv creates the new dc and env.
+func (z *zkStore) CreateDcAndEnv(dc, env string) error {
+	path := z.path("/%s", dc)
+	if err := z.ensurePath(path); err != nil {
+		return err
+	}
+
+	path = fmt.Sprintf("%s/%s", path, env)
+	return z.ensurePath(path)
+}
+
+// DeleteConfig deletes the config by the provided information.
+//
+//   1. dc must not be empty.
+//   2. If env is "", it should delete the whole dc.
+//   3. If app is "", it should delete the whole env.
+//   4. If key is "", it should delete the whole app.
+//   5. If _time is 0 or negative, it should delete the whole key.
+//
+// Notice: you can consider them as "/dc/env/app/key/_time".
+func (z *zkStore) DeleteConfig(dc, env, app, key string, _time int64) error {
+	if dc == "" {
+		return fmt.Errorf("dc is empty")
+	}
+	path := z.path("/%s", dc)
+	event := Event{Dc: dc, Deleted: true}
+
+	if env != "" {
+		path = fmt.Sprintf("%s/%s", path, env)
+		event.Env = env
+		if app != "" {
+			path = fmt.Sprintf("%s/%s", path, app)
+			event.App = app
+			if key != "" {
+				path = fmt.Sprintf("%s/%s", path, key)
+				event.Key = key
+				if _time > 0 {
+					path = fmt.Sprintf("%s/%d", path, _time)
+					event.Time = _time
+				}
+			}
+		}
+	}
+
+	if err := z.deletePathRecursion(path); err != nil && err != zk.ErrNoNode {
+		return err
+	}
+	if event.Time == 0 {
+		if err := z.deleteKeyRecords(event); err != nil {
+			return err
+		}
+	}
+	return z.addEvent(event)
+}
+
+// deleteKeyRecords deletes the records of the rollback, the metadata,
+// the draft, the canary and the schedule of all the keys under the dc, env,
+// app and key of the event, and the tags of the whole app.
+func (z *zkStore) deleteKeyRecords(event Event) error {
+	paths, err := z.getKeyRecords(event.Dc, event.Env, event.App, event.Key,
+		z.rollbackPath(""), z.metadataPath(""), z.draftPath(""),
+		z.canaryPath(""))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		if err = z.deletePathRecursion(path); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+	}
+	return nil
+}
+
+// getKeyRecords returns the paths of the nodes under roots, the names of
+// which are "dc#env#app#key", of all the keys under dc, env, app and key,
+// together with those of the schedules of the keys, and those of the tags
+// if key is "", that is, the tags are only of the whole app.
+func (z *zkStore) getKeyRecords(dc, env, app, key string, roots ...string) (
+	[]string, error) {
+	prefix := dc + "#"
+	for _, name := range []string{env, app, key} {
+		if name == "" {
+			break
+		}
+		prefix += name + "#"
+	}
+	if key != "" {
+		prefix = strings.TrimSuffix(prefix, "#")
+	} else {
+		roots = append(roots, z.tagPath(""))
+	}
+
+	paths := make([]string, 0, 16)
+	for _, root := range roots {
+		cs, _, err := z.zk.Children(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cs {
+			if (key != "" && c == prefix) ||
+				(key == "" && strings.HasPrefix(c, prefix)) {
+				paths = append(paths, fmt.Sprintf("%s/%s", root, c))
+			}
+		}
+	}
+
+	ids, _, err := z.zk.Children(z.schedulePath(""))
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		s, _, err := z.getSchedule(id)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if s.Dc == dc && (env == "" || s.Env == env) &&
+			(app == "" || s.App == app) && (key == "" || s.Key == key) {
+			paths = append(paths, z.schedulePath("/%s", id))
+		}
+	}
+	return paths, nil
+}
+
+// moveTree appends the requests to create the tree of from as to into creates,
+// and those to delete the tree of from into deletes.
+func (z *zkStore) moveTree(from, to string, creates, deletes *[]interface{}) error {
+	data, _, err := z.zk.Get(from)
+	if err != nil {
+		return err
+	}
+	*creates = append(*creates, &zk.CreateRequest{Path: to, Data: data,
+		Acl: z.acl, Flags: z.flags})
+
+	cs, _, err := z.zk.Children(from)
+	if err != nil {
+		return err
+	}
+	for _, c := range cs {
+		err = z.moveTree(fmt.Sprintf("%s/%s", from, c),
+			fmt.Sprintf("%s/%s", to, c), creates, deletes)
+		if err != nil {
+			return err
+		}
+	}
+
+	*deletes = append(*deletes, &zk.DeleteRequest{Path: from, Version: -1})
+	return nil
+}
+
+// MoveConfig moves the key of app, or the whole app if key is "",
+// by a multi-operation.
+func (z *zkStore) MoveConfig(dc, env, app, key, toApp, toKey string) error {
+	from := z.path("/%s/%s/%s", dc, env, app)
+	to := z.path("/%s/%s/%s", dc, env, toApp)
+	prefix := fmt.Sprintf("%s#%s#%s#", dc, env, app)
+	if key != "" {
+		from = fmt.Sprintf("%s/%s", from, key)
+		to = fmt.Sprintf("%s/%s", to, toKey)
+		prefix += key
+	}
+
+	if ok, _, err := z.zk.Exists(from); err != nil {
+		return err
+	} else if !ok {
+		return ErrNotFound
+	}
+	if ok, _, err := z.zk.Exists(to); err != nil {
+		return err
+	} else if ok {
+		return ErrExist
+	}
+
+	// Ensure the parent of the target key.
+	if key != "" {
+		if err := z.ensurePath(z.path("/%s/%s/%s", dc, env, toApp)); err != nil {
+			return err
+		}
+	}
+
+	creates := make([]interface{}, 0, 32)
+	deletes := make([]interface{}, 0, 32)
+	if err := z.moveTree(from, to, &creates, &deletes); err != nil {
+		return err
+	}
+
+	// Move the callbacks, the callback results, the records of the rollback,
+	// the drafts, the canaries and the metadata, the node names of which are
+	// "dc#env#app#key". The data of the last three holds the app and key, too.
+	roots := []string{z.cbPath(""), z.cbResultPath(""), z.rollbackPath(""),
+		z.draftPath(""), z.canaryPath(""), z.metadataPath("")}
+	for i, root := range roots {
+		cs, _, err := z.zk.Children(root)
+		if err != nil {
+			return err
+		}
+		for _, c := range cs {
+			if (key != "" && c != prefix) || (key == "" && !strings.HasPrefix(c, prefix)) {
+				continue
+			}
+
+			_key := toKey
+			if key == "" {
+				_key = strings.TrimPrefix(c, prefix)
+			}
+			_to := fmt.Sprintf("%s/%s#%s#%s#%s", root, dc, env, toApp, _key)
+			n := len(creates)
+			err = z.moveTree(fmt.Sprintf("%s/%s", root, c), _to, &creates, &deletes)
+			if err != nil {
+				return err
+			}
+			if i >= 3 {
+				req := creates[n].(*zk.CreateRequest)
+				if req.Data, err = moveData(req.Data, toApp, _key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Move the schedules, the nodes of which are named by the ID.
+	ids, _, err := z.zk.Children(z.schedulePath(""))
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		s, version, err := z.getSchedule(id)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		} else if s.Dc != dc || s.Env != env || s.App != app ||
+			(key != "" && s.Key != key) {
+			continue
+		}
+
+		s.App = toApp
+		if key != "" {
+			s.Key = toKey
+		}
+		data, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		creates = append(creates, &zk.SetDataRequest{Path: z.schedulePath("/%s",
+			id), Data: data, Version: version})
+	}
+
+	if _, err := z.zk.Multi(append(creates, deletes...)...); err == zk.ErrNodeExists {
+		return ErrExist
+	} else if err != nil {
+		return err
+	}
+
+	if err := z.addEvent(Event{Dc: dc, Env: env, App: app, Key: key,
+		Deleted: true}); err != nil {
+		return err
+	}
+
+	keys := []string{toKey}
+	if key == "" {
+		var err error
+		if keys, _, err = z.zk.Children(to); err != nil {
+			return err
+		}
+	}
+	for _, k := range keys {
+		value, version, err := z.AppGetConfig(dc, env, toApp, k, 0)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = z.addEvent(Event{Dc: dc, Env: env, App: toApp, Key: k,
+			Time: version, Value: value})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddChange creates the node of the change, the data of which is the change
+// as JSON.
+func (z *zkStore) AddChange(change Change) (Change, error) {
+	now := time.Now()
+	change.ID = newID(now)
+	change.Time = now.Unix()
+	change.Status = ChangePending
+	change.Revision = 1
+
+	data, err := json.Marshal(change)
+	if err != nil {
+		return Change{}, err
+	}
+	_, err = z.zk.Create(z.changePath("/%s", change.ID), data, z.flags, z.acl)
+	if err != nil {
+		return Change{}, err
+	}
+	return change, nil
+}
+
+// getChange returns the change and the version of its node.
+func (z *zkStore) getChange(id string) (change Change, version int32,
+	err error) {
+	data, stat, err := z.zk.Get(z.changePath("/%s", id))
+	if err == zk.ErrNoNode {
+		return change, 0, ErrNotFound
+	} else if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &change)
+	return change, stat.Version, err
+}
+
+// GetChanges returns the changes in the ascending order of the time.
+func (z *zkStore) GetChanges(dc, env, status string) ([]Change, error) {
+	cs, _, err := z.zk.Children(z.changePath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(cs))
+	for _, id := range cs {
+		c, _, err := z.getChange(id)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if (dc == "" || c.Dc == dc) && (env == "" || c.Env == env) &&
+			(status == "" || c.Status == status) {
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Time == changes[j].Time {
+			return changes[i].ID < changes[j].ID
+		}
+		return changes[i].Time < changes[j].Time
+	})
+	return changes, nil
+}
+
+// GetChange returns the change identified by id.
+func (z *zkStore) GetChange(id string) (Change, error) {
+	c, _, err := z.getChange(id)
+	return c, err
+}
+
+// UpdateChange sets the data of the node of the change with the version
+// of the node, which fails if the node has been changed by others.
+func (z *zkStore) UpdateChange(change Change) (Change, error) {
+	old, version, err := z.getChange(change.ID)
+	if err != nil {
+		return Change{}, err
+	} else if old.Revision != change.Revision {
+		return Change{}, ErrConflict
+	}
+
+	change.Revision++
+	data, err := json.Marshal(change)
+	if err != nil {
+		return Change{}, err
+	}
+
+	_, err = z.zk.Set(z.changePath("/%s", change.ID), data, version)
+	if err == zk.ErrBadVersion {
+		return Change{}, ErrConflict
+	} else if err == zk.ErrNoNode {
+		return Change{}, ErrNotFound
+	} else if err != nil {
+		return Change{}, err
+	}
+	return change, nil
+}
+
+// AddSchedule creates the node of the schedule, the data of which is
+// the schedule as JSON.
+func (z *zkStore) AddSchedule(schedule Schedule) (Schedule, error) {
+	now := time.Now()
+	schedule.ID = newID(now)
+	schedule.Time = now.Unix()
+
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		return Schedule{}, err
+	}
+	_, err = z.zk.Create(z.schedulePath("/%s", schedule.ID), data, z.flags,
+		z.acl)
+	if err != nil {
+		return Schedule{}, err
+	}
+	return schedule, nil
+}
+
+// getSchedule returns the schedule and the version of its node.
+func (z *zkStore) getSchedule(id string) (schedule Schedule, version int32,
+	err error) {
+	data, stat, err := z.zk.Get(z.schedulePath("/%s", id))
+	if err == zk.ErrNoNode {
+		return schedule, 0, ErrNotFound
+	} else if err != nil {
+		return
+	}
+	err = json.Unmarshal(data, &schedule)
+	return schedule, stat.Version, err
+}
+
+// GetSchedules returns the schedules in the ascending order of the time.
+func (z *zkStore) GetSchedules(dc, env, app string) ([]Schedule, error) {
+	ids, _, err := z.zk.Children(z.schedulePath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make([]Schedule, 0, len(ids))
+	for _, id := range ids {
+		s, _, err := z.getSchedule(id)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if (dc == "" || s.Dc == dc) && (env == "" || s.Env == env) &&
+			(app == "" || s.App == app) {
+			schedules = append(schedules, s)
+		}
+	}
+	sortSchedules(schedules)
+	return schedules, nil
+}
+
+// DeleteSchedule deletes the node of the schedule, which fails with
+// ErrNotFound if it has been deleted by others.
+func (z *zkStore) DeleteSchedule(id string) (Schedule, error) {
+	s, version, err := z.getSchedule(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	err = z.zk.Delete(z.schedulePath("/%s", id), version)
+	if err == zk.ErrNoNode || err == zk.ErrBadVersion {
+		return Schedule{}, ErrNotFound
+	} else if err != nil {
+		return Schedule{}, err
+	}
+	return s, nil
+}
+
+// ApplySchedule creates the node of the value and deletes the node of
+// the schedule by a multi-operation, which is claimed by the version of
+// the schedule node.
+func (z *zkStore) ApplySchedule(id string) (Schedule, error) {
+	s, version, err := z.getSchedule(id)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	if ok, _, err := z.zk.Exists(z.path("/%s/%s", s.Dc, s.Env)); err != nil {
+		return Schedule{}, err
+	} else if !ok {
+		return Schedule{}, ErrNoDcAndEnv
+	}
+	if err = z.ensurePath(z.path("/%s/%s/%s", s.Dc, s.Env, s.App)); err != nil {
+		return Schedule{}, err
+	}
+	if err = z.ensurePath(z.path("/%s/%s/%s/%s", s.Dc, s.Env, s.App, s.Key)); err != nil {
+		return Schedule{}, err
+	}
+
+	now := time.Now().Unix()
+	_, err = z.zk.Multi(
+		&zk.DeleteRequest{Path: z.schedulePath("/%s", id), Version: version},
+		&zk.CreateRequest{Path: z.path("/%s/%s/%s/%s/%d", s.Dc, s.Env, s.App,
+			s.Key, now), Data: []byte(s.Value), Acl: z.acl, Flags: z.flags},
+	)
+	if err == zk.ErrNoNode || err == zk.ErrBadVersion {
+		return Schedule{}, ErrNotFound
+	} else if err != nil {
+		return Schedule{}, err
+	}
+
+	return s, z.addEvent(Event{Dc: s.Dc, Env: s.Env, App: s.App, Key: s.Key,
+		Time: now, Value: s.Value})
+}
+
+func (z *zkStore) getCanaryPath(dc, env, app, key string) string {
+	return z.canaryPath("/%s#%s#%s#%s", dc, env, app, key)
+}
+
+// AddCanary creates the node of the canary, the data of which is the canary
+// as JSON. The node names of the canaries are "dc#env#app#key".
+func (z *zkStore) AddCanary(canary Canary) (Canary, error) {
+	canary.Time = time.Now().Unix()
+	data, err := json.Marshal(canary)
+	if err != nil {
+		return Canary{}, err
+	}
+
+	path := z.getCanaryPath(canary.Dc, canary.Env, canary.App, canary.Key)
+	if _, err = z.zk.Create(path, data, z.flags, z.acl); err == zk.ErrNodeExists {
+		return Canary{}, ErrExist
+	} else if err != nil {
+		return Canary{}, err
+	}
+	return canary, nil
+}
+
+// UpdateCanary sets the data of the node of the canary.
+func (z *zkStore) UpdateCanary(canary Canary) (Canary, error) {
+	canary.Time = time.Now().Unix()
+	data, err := json.Marshal(canary)
+	if err != nil {
+		return Canary{}, err
+	}
+
+	path := z.getCanaryPath(canary.Dc, canary.Env, canary.App, canary.Key)
+	if _, err = z.zk.Set(path, data, -1); err == zk.ErrNoNode {
+		return Canary{}, ErrNotFound
+	} else if err != nil {
+		return Canary{}, err
+	}
+	return canary, nil
+}
+
+// GetCanaries returns the canaries of the app in the order of the key.
+func (z *zkStore) GetCanaries(dc, env, app string) ([]Canary, error) {
+	cs, _, err := z.zk.Children(z.canaryPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s#%s#%s#", dc, env, app)
+	canaries := make([]Canary, 0, 8)
+	for _, c := range cs {
+		if !strings.HasPrefix(c, prefix) {
+			continue
+		}
+
+		canary, err := z.GetCanary(dc, env, app, strings.TrimPrefix(c, prefix))
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		canaries = append(canaries, canary)
+	}
+	sort.Slice(canaries, func(i, j int) bool {
+		return canaries[i].Key < canaries[j].Key
+	})
+	return canaries, nil
+}
+
+// GetCanary returns the canary of the key.
+func (z *zkStore) GetCanary(dc, env, app, key string) (Canary, error) {
+	var canary Canary
+	data, _, err := z.zk.Get(z.getCanaryPath(dc, env, app, key))
+	if err == zk.ErrNoNode {
+		return canary, ErrNotFound
+	} else if err != nil {
+		return canary, err
+	}
+	err = json.Unmarshal(data, &canary)
+	return canary, err
+}
+
+// DeleteCanary deletes the node of the canary.
+func (z *zkStore) DeleteCanary(dc, env, app, key string) error {
+	err := z.zk.Delete(z.getCanaryPath(dc, env, app, key), -1)
+	if err == zk.ErrNoNode {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SetDependencies sets the data of the node of the dependencies as
+// the namespaces as JSON. The node names are "dc#env#app".
+func (z *zkStore) SetDependencies(dc, env, app string,
+	namespaces []string) error {
+	path := z.dependencyPath("/%s#%s#%s", dc, env, app)
+	if len(namespaces) == 0 {
+		if err := z.zk.Delete(path, -1); err != nil && err != zk.ErrNoNode {
+			return err
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(namespaces)
+	if err != nil {
+		return err
+	}
+	_, err = z.zk.Set(path, data, -1)
+	if err == zk.ErrNoNode {
+		_, err = z.zk.Create(path, data, z.flags, z.acl)
+	}
+	return err
+}
+
+// GetDependencies returns the namespaces in the order of the priority.
+func (z *zkStore) GetDependencies(dc, env, app string) ([]string, error) {
+	data, _, err := z.zk.Get(z.dependencyPath("/%s#%s#%s", dc, env, app))
+	if err == zk.ErrNoNode {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	err = json.Unmarshal(data, &namespaces)
+	return namespaces, err
+}
+
+// getMetadataPath returns the path of the node of the metadata of the key,
+// the name of which is "dc#env#app#key".
+func (z *zkStore) getMetadataPath(dc, env, app, key string) string {
+	return z.metadataPath("/%s#%s#%s#%s", dc, env, app, key)
+}
+
+// SetMetadata sets the data of the node of the metadata as JSON.
+func (z *zkStore) SetMetadata(metadata Metadata) (Metadata, error) {
+	metadata.Time = time.Now().Unix()
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	path := z.getMetadataPath(metadata.Dc, metadata.Env, metadata.App,
+		metadata.Key)
+	_, err = z.zk.Set(path, data, -1)
+	if err == zk.ErrNoNode {
+		_, err = z.zk.Create(path, data, z.flags, z.acl)
+	}
+	if err != nil {
+		return Metadata{}, err
+	}
+	return metadata, nil
+}
+
+// GetMetadata returns the metadata of the key.
+func (z *zkStore) GetMetadata(dc, env, app, key string) (Metadata, error) {
+	var metadata Metadata
+	data, _, err := z.zk.Get(z.getMetadataPath(dc, env, app, key))
+	if err == zk.ErrNoNode {
+		return metadata, ErrNotFound
+	} else if err != nil {
+		return metadata, err
+	}
+	err = json.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+// GetAllMetadata returns the metadata of all the keys of the app.
+func (z *zkStore) GetAllMetadata(dc, env, app string) (map[string]Metadata,
+	error) {
+	cs, _, err := z.zk.Children(z.metadataPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s#%s#%s#", dc, env, app)
+	metadata := make(map[string]Metadata, 8)
+	for _, c := range cs {
+		if !strings.HasPrefix(c, prefix) {
+			continue
+		}
+
+		key := strings.TrimPrefix(c, prefix)
+		md, err := z.GetMetadata(dc, env, app, key)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		metadata[key] = md
+	}
+	return metadata, nil
+}
+
+// DeleteMetadata deletes the node of the metadata.
+func (z *zkStore) DeleteMetadata(dc, env, app, key string) error {
+	err := z.zk.Delete(z.getMetadataPath(dc, env, app, key), -1)
+	if err == zk.ErrNoNode {
+		return ErrNotFound
+	}
+	return err
+}
+
+// GetDependents returns the apps depending on the namespace.
+func (z *zkStore) GetDependents(dc, env, namespace string) ([]string,
+	error) {
+	cs, _, err := z.zk.Children(z.dependencyPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s#%s#", dc, env)
+	apps := make([]string, 0, 8)
+	for _, c := range cs {
+		if !strings.HasPrefix(c, prefix) {
+			continue
+		}
+
+		app := strings.TrimPrefix(c, prefix)
+		namespaces, err := z.GetDependencies(dc, env, app)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range namespaces {
+			if ns == namespace {
+				apps = append(apps, app)
+				break
+			}
+		}
+	}
+	sort.Strings(apps)
+	return apps, nil
+}
+
+func (z *zkStore) getDraftPath(dc, env, app, key string) string {
+	return z.draftPath("/%s#%s#%s#%s", dc, env, app, key)
+}
+
+// SetDraft sets the data of the node of the draft as the draft as JSON.
+// The node names of the drafts are "dc#env#app#key".
+func (z *zkStore) SetDraft(dc, env, app, key, value string) error {
+	if ok, _, err := z.zk.Exists(z.path("/%s/%s", dc, env)); err != nil {
+		return err
+	} else if !ok {
+		return ErrNoDcAndEnv
+	}
+
+	data, err := json.Marshal(Draft{Key: key, Value: value,
+		Time: time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+
+	path := z.getDraftPath(dc, env, app, key)
+	_, err = z.zk.Create(path, data, z.flags, z.acl)
+	if err == zk.ErrNodeExists {
+		_, err = z.zk.Set(path, data, -1)
+	}
+	return err
+}
+
+// GetDrafts returns all the drafts of the app in the order of the key.
+func (z *zkStore) GetDrafts(dc, env, app string) ([]Draft, error) {
+	cs, _, err := z.zk.Children(z.draftPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s#%s#%s#", dc, env, app)
+	drafts := make([]Draft, 0, 8)
+	for _, c := range cs {
+		if !strings.HasPrefix(c, prefix) {
+			continue
+		}
+
+		data, _, err := z.zk.Get(z.draftPath("/%s", c))
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var draft Draft
+		if err = json.Unmarshal(data, &draft); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+	sort.Slice(drafts, func(i, j int) bool { return drafts[i].Key < drafts[j].Key })
+	return drafts, nil
+}
+
+// DeleteDrafts deletes the node of the draft of the key, or all the drafts
+// of the app.
+func (z *zkStore) DeleteDrafts(dc, env, app, key string) error {
+	keys := []string{key}
+	if key == "" {
+		drafts, err := z.GetDrafts(dc, env, app)
+		if err != nil {
+			return err
+		}
+		keys = keys[:0]
+		for _, draft := range drafts {
+			keys = append(keys, draft.Key)
+		}
+	}
+
+	var n int
+	for _, k := range keys {
+		err := z.zk.Delete(z.getDraftPath(dc, env, app, k), -1)
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// PublishDrafts creates the values of the drafts, and deletes the nodes
+// of the drafts, by a multi-operation.
+func (z *zkStore) PublishDrafts(dc, env, app string) (map[string]Version,
+	error) {
+	drafts, err := z.GetDrafts(dc, env, app)
+	if err != nil {
+		return nil, err
+	} else if len(drafts) == 0 {
+		return nil, ErrNotFound
+	}
+
+	// Ensure the path /dc/env/app/key of each draft.
+	p := z.path("/%s/%s", dc, env)
+	if ok, _, err := z.zk.Exists(p); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrNoDcAndEnv
+	}
+	p = fmt.Sprintf("%s/%s", p, app)
+	if err = z.ensurePath(p); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	published := make(map[string]Version, len(drafts))
+	ops := make([]interface{}, 0, len(drafts)*2)
+	for _, draft := range drafts {
+		path := fmt.Sprintf("%s/%s", p, draft.Key)
+		if err = z.ensurePath(path); err != nil {
+			return nil, err
+		}
+
+		path = fmt.Sprintf("%s/%d", path, now)
+		if ok, _, err := z.zk.Exists(path); err != nil {
+			return nil, err
+		} else if ok {
+			ops = append(ops, &zk.SetDataRequest{Path: path,
+				Data: []byte(draft.Value), Version: -1})
+		} else {
+			ops = append(ops, &zk.CreateRequest{Path: path,
+				Data: []byte(draft.Value), Acl: z.acl, Flags: z.flags})
+		}
+		ops = append(ops, &zk.DeleteRequest{Version: -1,
+			Path: z.getDraftPath(dc, env, app, draft.Key)})
+		published[draft.Key] = Version{Time: now, Value: draft.Value}
+	}
+
+	if _, err = z.zk.Multi(ops...); err != nil {
+		return nil, err
+	}
+
+	for _, draft := range drafts {
+		err = z.addEvent(Event{Dc: dc, Env: env, App: app, Key: draft.Key,
+			Time: now, Value: draft.Value})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return published, nil
+}
+
+func (z *zkStore) getTagPath(dc, env, app, name string) string {
+	return z.tagPath("/%s#%s#%s#%s", dc, env, app, name)
+}
+
+// CreateTag creates the node of the tag, the data of which is the tag
+// as JSON. The node names of the tags are "dc#env#app#name".
+func (z *zkStore) CreateTag(dc, env, app, name string) (Tag, error) {
+	path := z.getTagPath(dc, env, app, name)
+	if ok, _, err := z.zk.Exists(path); err != nil {
+		return Tag{}, err
+	} else if ok {
+		return Tag{}, ErrExist
+	}
+
+	keys, _, err := z.zk.Children(z.path("/%s/%s/%s", dc, env, app))
+	if err == zk.ErrNoNode {
+		return Tag{}, ErrNotFound
+	} else if err != nil {
+		return Tag{}, err
+	}
+
+	tag := Tag{Name: name, Time: time.Now().Unix(),
+		Versions: make(map[string]int64, len(keys))}
+	for _, key := range keys {
+		cs, _, err := z.zk.Children(z.path("/%s/%s/%s/%s", dc, env, app, key))
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return Tag{}, err
+		}
+		for _, c := range cs {
+			if t, err := types.ToInt64(c); err == nil && t > tag.Versions[key] {
+				tag.Versions[key] = t
+			}
+		}
+	}
+	if len(tag.Versions) == 0 {
+		return Tag{}, ErrNotFound
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return Tag{}, err
+	}
+	if _, err = z.zk.Create(path, data, z.flags, z.acl); err == zk.ErrNodeExists {
+		return Tag{}, ErrExist
+	} else if err != nil {
+		return Tag{}, err
+	}
+	return tag, nil
+}
+
+// GetTags returns all the tags of the app in the ascending order of the time.
+func (z *zkStore) GetTags(dc, env, app string) ([]Tag, error) {
+	cs, _, err := z.zk.Children(z.tagPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := fmt.Sprintf("%s#%s#%s#", dc, env, app)
+	tags := make([]Tag, 0, 8)
+	for _, c := range cs {
+		if !strings.HasPrefix(c, prefix) {
+			continue
+		}
+
+		tag, err := z.GetTag(dc, env, app, strings.TrimPrefix(c, prefix))
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Time == tags[j].Time {
+			return tags[i].Name < tags[j].Name
+		}
+		return tags[i].Time < tags[j].Time
+	})
+	return tags, nil
+}
+
+// GetTag returns the tag of the app.
+func (z *zkStore) GetTag(dc, env, app, name string) (Tag, error) {
+	var tag Tag
+	data, _, err := z.zk.Get(z.getTagPath(dc, env, app, name))
+	if err == zk.ErrNoNode {
+		return tag, ErrNotFound
+	} else if err != nil {
+		return tag, err
+	}
+	err = json.Unmarshal(data, &tag)
+	return tag, err
+}
+
+// DeleteTag deletes the node of the tag.
+func (z *zkStore) DeleteTag(dc, env, app, name string) error {
+	err := z.zk.Delete(z.getTagPath(dc, env, app, name), -1)
+	if err == zk.ErrNoNode {
+		return ErrNotFound
+	}
+	return err
+}
+
+// zkBatchOps and zkBatchBytes bound the number of the operations and
+// the size of the data of a multi-operation, the request of which is limited
+// by jute.maxbuffer of ZooKeeper, 1MB by default.
+const (
+	zkBatchOps   = 100
+	zkBatchBytes = 512 * 1024
+)
+
+// multiBatches executes ops in turn by the multi-operations in batches,
+// each of which is bounded by zkBatchOps and zkBatchBytes. So the batches
+// executed before a failed one are not rolled back.
+func (z *zkStore) multiBatches(ops []interface{}) error {
+	for len(ops) > 0 {
+		n, size := 0, 0
+		for ; n < len(ops) && n < zkBatchOps; n++ {
+			if c, ok := ops[n].(*zk.CreateRequest); ok {
+				if n > 0 && size+len(c.Path)+len(c.Data) > zkBatchBytes {
+					break
+				}
+				size += len(c.Path) + len(c.Data)
+			}
+		}
+
+		if _, err := z.zk.Multi(ops[:n]...); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// zkTrash is the data of the node of the trash, the children of which mirror
+// the trees of the config and the records relative to the root, such as
+// "config/dc/env/app/key/time" and "draft/dc#env#app#key".
+type zkTrash struct {
+	Trash
+
+	// Done is true only after all the nodes have been copied into the trash.
+	Done bool `json:"done"`
+}
+
+// getRelPath returns path relative to the root.
+func (z *zkStore) getRelPath(path string) string {
+	if z.root != "/" {
+		return strings.TrimPrefix(path, z.root)
+	}
+	return path
+}
+
+// TrashConfig moves the tree of the config, together with the callbacks,
+// the callback results, the records of the rollback, the metadata,
+// the drafts, the canaries, the schedules and the tags, into the tree of
+// a node of the trash.
+//
+// The nodes are copied into the trash in batches, then the trash is marked
+// done, and then the nodes are deleted in batches. So a trash interrupted
+// before done is ignored, and the config interrupted while deleted has been
+// in the trash.
+func (z *zkStore) TrashConfig(dc, env, app, key string) (Trash, error) {
+	trash := newTrash(dc, env, app, key)
+	path := z.path("/%s", trash.Dc)
+	for _, name := range []string{trash.Env, trash.App, trash.Key} {
+		if name == "" {
+			break
+		}
+		path = fmt.Sprintf("%s/%s", path, name)
+	}
+
+	if ok, _, err := z.zk.Exists(path); err != nil {
+		return Trash{}, err
+	} else if !ok {
+		return Trash{}, ErrNotFound
+	}
 
-	return
-}
+	paths, err := z.getKeyRecords(trash.Dc, trash.Env, trash.App, trash.Key,
+		z.cbPath(""), z.cbResultPath(""), z.rollbackPath(""),
+		z.metadataPath(""), z.draftPath(""), z.canaryPath(""))
+	if err != nil {
+		return Trash{}, err
+	}
 
-// AppGetConfig is used by the app to get the value of the key in APP.
-//
-// If the time is 0 or negative, it should return the latest value.
-// Or it should return the value at the provided time.
-func (z *zkStore) AppGetConfig(dc, env, app, key string, _time int64) (v string,
-	err error) {
+	zt := zkTrash{Trash: trash}
+	data, err := json.Marshal(zt)
+	if err != nil {
+		return Trash{}, err
+	}
+	root := z.trashPath("/%s", trash.ID)
+	creates := []interface{}{&zk.CreateRequest{Path: root, Data: data,
+		Acl: z.acl, Flags: z.flags}}
+	deletes := make([]interface{}, 0, 32)
+
+	// Create the parents of the trees in the trash, such as "config/dc/env"
+	// and "draft", which are not moved.
+	parents := make(map[string]struct{}, 8)
+	for _, p := range append([]string{path}, paths...) {
+		names := strings.Split(strings.TrimPrefix(z.getRelPath(p), "/"), "/")
+		parent := root
+		for _, name := range names[:len(names)-1] {
+			parent = fmt.Sprintf("%s/%s", parent, name)
+			if _, ok := parents[parent]; !ok {
+				parents[parent] = struct{}{}
+				creates = append(creates, &zk.CreateRequest{Path: parent,
+					Acl: z.acl, Flags: z.flags})
+			}
+		}
 
-	if _time > 0 {
-		path := z.path("/%s/%s/%s/%s/%d", dc, env, app, key, _time)
-		data, _, err := z.zk.Get(path)
-		switch err {
-		case nil:
-			return string(data), nil
-		case zk.ErrNoNode:
-			return "", ErrNotFound
-		default:
-			return "", err
+		err = z.moveTree(p, root+z.getRelPath(p), &creates, &deletes)
+		if err != nil {
+			return Trash{}, err
 		}
 	}
 
-	// Get all the childrens, that's the timestamps.
-	path := z.path("/%s/%s/%s/%s", dc, env, app, key)
-	cs, _, err := z.zk.Children(path)
-	switch err {
-	case nil:
-	case zk.ErrNoNode:
-		return "", ErrNotFound
-	default:
-		return
+	if err = z.multiBatches(creates); err != nil {
+		// The incomplete trash is ignored even if it fails to be deleted.
+		z.deletePathRecursion(root)
+		return Trash{}, err
 	}
 
-	if len(cs) == 0 {
-		return "", ErrNotFound
+	zt.Done = true
+	if data, err = json.Marshal(zt); err != nil {
+		return Trash{}, err
+	} else if _, err = z.zk.Set(root, data, -1); err != nil {
+		return Trash{}, err
 	}
 
-	// Get the lastest timestamp
-	sort.Sort(sort.Reverse(sort.StringSlice(cs)))
-	path = fmt.Sprintf("%s/%s", path, cs[0])
+	if err = z.multiBatches(deletes); err != nil {
+		return Trash{}, err
+	}
+	return trash, z.addEvent(Event{Dc: trash.Dc, Env: trash.Env, App: trash.App,
+		Key: trash.Key, Deleted: true})
+}
 
-	// Get the data
-	data, _, err := z.zk.Get(path)
-	switch err {
-	case nil:
-		return string(data), nil
-	case zk.ErrNoNode:
-		return "", ErrNotFound
-	default:
-		return
+// getTrash returns the data of the node of the trash, which returns
+// ErrNotFound if the trash is not done.
+func (z *zkStore) getTrash(id string) (zkTrash, error) {
+	var zt zkTrash
+	data, _, err := z.zk.Get(z.trashPath("/%s", id))
+	if err == zk.ErrNoNode {
+		return zt, ErrNotFound
+	} else if err != nil {
+		return zt, err
+	} else if err = json.Unmarshal(data, &zt); err != nil {
+		return zt, err
+	} else if !zt.Done {
+		return zt, ErrNotFound
 	}
+	return zt, nil
 }
 
-// CreateDcAndEnv creates the new dc and env.
-func (z *zkStore) CreateDcAndEnv(dc, env string) error {
-	path := z.path("/%s", dc)
-	if err := z.ensurePath(path); err != nil {
-		return err
+// GetTrashes returns all the trashes in the ascending order of the time.
+func (z *zkStore) GetTrashes() ([]Trash, error) {
+	cs, _, err := z.zk.Children(z.trashPath(""))
+	if err != nil {
+		return nil, err
 	}
 
-	path = fmt.Sprintf("%s/%s", path, env)
-	return z.ensurePath(path)
+	trashes := make([]Trash, 0, len(cs))
+	for _, c := range cs {
+		zt, err := z.getTrash(c)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		trashes = append(trashes, zt.Trash)
+	}
+	sort.Slice(trashes, func(i, j int) bool {
+		if trashes[i].Time == trashes[j].Time {
+			return trashes[i].ID < trashes[j].ID
+		}
+		return trashes[i].Time < trashes[j].Time
+	})
+	return trashes, nil
 }
 
-// DeleteConfig deletes the config by the provided information.
-//
-//   1. dc must not be empty.
-//   2. If env is "", it should delete the whole dc.
-//   3. If app is "", it should delete the whole env.
-//   4. If key is "", it should delete the whole app.
-//   5. If _time is 0 or negative, it should delete the whole key.
+// RestoreTrash moves the trees in the trash back in batches, and then
+// deletes the trash in batches.
 //
-// Notice: you can consider them as "/dc/env/app/key/_time".
-func (z *zkStore) DeleteConfig(dc, env, app, key string, _time int64) error {
-	if dc == "" {
-		return fmt.Errorf("dc is empty")
+// The nodes of the roots, dc, env and app, which have existed, are not
+// created again. But if any other node, such as a key, its callback or its
+// draft, has existed, it returns ErrExist.
+func (z *zkStore) RestoreTrash(id string) (Trash, error) {
+	zt, err := z.getTrash(id)
+	if err != nil {
+		return Trash{}, err
 	}
-	path := z.path("/%s", dc)
 
-	if env != "" {
-		path = fmt.Sprintf("%s/%s", path, env)
-		if app != "" {
-			path = fmt.Sprintf("%s/%s", path, app)
-			if key != "" {
-				path = fmt.Sprintf("%s/%s", path, key)
-				if _time > 0 {
-					path = fmt.Sprintf("%s/%d", path, _time)
-				}
+	root := z.trashPath("/%s", id)
+	cs, _, err := z.zk.Children(root)
+	if err != nil {
+		return Trash{}, err
+	}
+
+	var moves, deletes []interface{}
+	for _, c := range cs {
+		to := "/" + c
+		if z.root != "/" {
+			to = z.root + to
+		}
+		err = z.moveTree(fmt.Sprintf("%s/%s", root, c), to, &moves, &deletes)
+		if err != nil {
+			return Trash{}, err
+		}
+	}
+	deletes = append(deletes, &zk.DeleteRequest{Path: root, Version: -1})
+
+	// The depth of the node of the key is 4 under the config.
+	config := z.path("") + "/"
+	keys := make([][4]string, 0, 8)
+	creates := make([]interface{}, 0, len(moves))
+	for _, op := range moves {
+		path := op.(*zk.CreateRequest).Path
+		var names []string
+		if strings.HasPrefix(path, config) {
+			names = strings.Split(strings.TrimPrefix(path, config), "/")
+		}
+		parent := len(names) < 4 && (len(names) > 0 ||
+			!strings.Contains(strings.TrimPrefix(z.getRelPath(path), "/"), "/"))
+
+		if ok, _, err := z.zk.Exists(path); err != nil {
+			return Trash{}, err
+		} else if ok {
+			if parent {
+				continue
 			}
+			return Trash{}, ErrExist
+		}
+
+		if len(names) == 4 {
+			keys = append(keys, [4]string{names[0], names[1], names[2], names[3]})
 		}
+		creates = append(creates, op)
 	}
 
-	err := z.deletePathRecursion(path)
+	if err = z.multiBatches(creates); err != nil {
+		return Trash{}, err
+	} else if err = z.multiBatches(deletes); err != nil {
+		return Trash{}, err
+	}
+
+	for _, k := range keys {
+		value, version, err := z.AppGetConfig(k[0], k[1], k[2], k[3], 0)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return Trash{}, err
+		}
+		err = z.addEvent(Event{Dc: k[0], Env: k[1], App: k[2], Key: k[3],
+			Time: version, Value: value})
+		if err != nil {
+			return Trash{}, err
+		}
+	}
+	return zt.Trash, nil
+}
+
+// PurgeTrash deletes the tree of the trash permanently, including the trash
+// which is not done.
+func (z *zkStore) PurgeTrash(id string) error {
+	err := z.deletePathRecursion(z.trashPath("/%s", id))
 	if err == zk.ErrNoNode {
-		return nil
+		return ErrNotFound
 	}
 	return err
 }
@@ -304,9 +1615,11 @@ func (z *zkStore) SetKeyValue(dc, env, app, key, value string) error {
 
 	// First retry to set the value.
 	// If there is not the parent node, create it, then retry to set the value.
-	path := z.path("/%s/%s/%s/%s/%d", dc, env, app, key, time.Now().Unix())
+	now := time.Now().Unix()
+	path := z.path("/%s/%s/%s/%s/%d", dc, env, app, key, now)
 	if _, err := z.zk.Create(path, data, z.flags, z.acl); err == nil {
-		return nil
+		return z.addEvent(Event{Dc: dc, Env: env, App: app, Key: key,
+			Time: now, Value: value})
 	} else if err != zk.ErrNoNode {
 		return err
 	}
@@ -332,8 +1645,58 @@ func (z *zkStore) SetKeyValue(dc, env, app, key, value string) error {
 	}
 
 	// Set the value of the path repeatedly.
-	_, err := z.zk.Create(path, data, z.flags, z.acl)
-	return err
+	if _, err := z.zk.Create(path, data, z.flags, z.acl); err != nil {
+		return err
+	}
+	return z.addEvent(Event{Dc: dc, Env: env, App: app, Key: key, Time: now,
+		Value: value})
+}
+
+// SetKeyValues sets the values of the key in dc, evn and app with the given
+// versions.
+func (z *zkStore) SetKeyValues(dc, env, app, key string,
+	values map[int64]string) error {
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	// Ensure the path /dc/env.
+	p := z.path("/%s/%s", dc, env)
+	if ok, _, err := z.zk.Exists(p); err != nil {
+		return err
+	} else if !ok {
+		return ErrNoDcAndEnv
+	}
+
+	// Ensure the path /dc/env/app/key.
+	p = fmt.Sprintf("%s/%s", p, app)
+	if err := z.ensurePath(p); err != nil {
+		return err
+	}
+	p = fmt.Sprintf("%s/%s", p, key)
+	if err := z.ensurePath(p); err != nil {
+		return err
+	}
+
+	var latest int64
+	for t, v := range values {
+		path := fmt.Sprintf("%s/%d", p, t)
+		_, err := z.zk.Create(path, []byte(v), z.flags, z.acl)
+		if err == zk.ErrNodeExists {
+			_, err = z.zk.Set(path, []byte(v), -1)
+		}
+		if err != nil {
+			return err
+		}
+
+		if t > latest {
+			latest = t
+		}
+	}
+
+	return z.addEvent(Event{Dc: dc, Env: env, App: app, Key: key, Time: latest,
+		Value: values[latest]})
 }
 
 func (z *zkStore) ensurePath(path string) (err error) {
@@ -463,6 +1826,262 @@ func (z *zkStore) GetAllValues(dc, env, app, key string, page, number, from,
 	return total, values, nil
 }
 
+// addEvent records a change event as a sequential node under the event path,
+// the sequence number of which is the id of the event.
+func (z *zkStore) addEvent(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	path, err := z.zk.Create(z.eventPath("/e-"), data, z.flags|zk.FlagSequence,
+		z.acl)
+	if err != nil {
+		return err
+	}
+
+	// Discard the old events every 100 events.
+	if id := z.getEventID(path); id > 0 && id%100 == 0 {
+		return z.discardEvents(id - maxEvents)
+	}
+	return nil
+}
+
+// getEventID returns the id of the event from the name of the sequential node.
+func (z *zkStore) getEventID(path string) int64 {
+	index := strings.LastIndex(path, "e-")
+	if index < 0 {
+		return 0
+	}
+
+	// The sequence number starts with 0, but the id starts with 1.
+	seq, err := strconv.ParseInt(path[index+2:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return seq + 1
+}
+
+// discardEvents deletes the events, the id of which is not greater than id.
+func (z *zkStore) discardEvents(id int64) error {
+	cs, _, err := z.zk.Children(z.eventPath(""))
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cs {
+		if z.getEventID(c) <= id {
+			err := z.zk.Delete(z.eventPath("/%s", c), -1)
+			if err != nil && err != zk.ErrNoNode {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetEvents returns the change events, the id of which is greater than
+// since, in the ascending order of the id.
+func (z *zkStore) GetEvents(since, number int64) ([]Event, error) {
+	cs, _, err := z.zk.Children(z.eventPath(""))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(cs))
+	names := make(map[int64]string, len(cs))
+	for _, c := range cs {
+		if id := z.getEventID(c); id > since {
+			ids = append(ids, id)
+			names[id] = c
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if number > 0 && int64(len(ids)) > number {
+		ids = ids[:number]
+	}
+
+	events := make([]Event, 0, len(ids))
+	for _, id := range ids {
+		data, _, err := z.zk.Get(z.eventPath("/%s", names[id]))
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var event Event
+		if err = json.Unmarshal(data, &event); err != nil {
+			return nil, err
+		}
+		event.ID = id
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// GetLastEventID returns the id of the latest change event.
+func (z *zkStore) GetLastEventID() (int64, error) {
+	cs, _, err := z.zk.Children(z.eventPath(""))
+	if err != nil {
+		return 0, err
+	}
+
+	var last int64
+	for _, c := range cs {
+		if id := z.getEventID(c); id > last {
+			last = id
+		}
+	}
+	return last, nil
+}
+
+func (z *zkStore) getRollbackPath(dc, env, app, key string) string {
+	return z.rollbackPath("/%s#%s#%s#%s", dc, env, app, key)
+}
+
+// zkAsOfConcurrency is the maximum number of the keys looked up concurrently
+// by GetConfigAsOf.
+const zkAsOfConcurrency = 32
+
+// GetConfigAsOf returns the newest versions at or before at of the keys,
+// which are the children of the node of the key.
+//
+// At most zkAsOfConcurrency keys are looked up concurrently, the requests
+// of which are pipelined by the ZooKeeper session.
+func (z *zkStore) GetConfigAsOf(dc, env, app, key string, at int64) (
+	map[string]Version, error) {
+	keys := []string{key}
+	if key == "" {
+		var err error
+		keys, _, err = z.zk.Children(z.path("/%s/%s/%s", dc, env, app))
+		if err == zk.ErrNoNode {
+			return map[string]Version{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	versions := make([]Version, len(keys))
+	errs := make([]error, len(keys))
+	sem := make(chan struct{}, zkAsOfConcurrency)
+	var wg sync.WaitGroup
+	for i, k := range keys {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, path string) {
+			defer func() { <-sem; wg.Done() }()
+			versions[i], errs[i] = z.getVersionAsOf(path, at)
+		}(i, z.path("/%s/%s/%s/%s", dc, env, app, k))
+	}
+	wg.Wait()
+
+	result := make(map[string]Version, len(keys))
+	for i, k := range keys {
+		if errs[i] != nil {
+			return nil, errs[i]
+		} else if versions[i].Time > 0 {
+			result[k] = versions[i]
+		}
+	}
+	return result, nil
+}
+
+// getVersionAsOf returns the newest version at or before at of the key node
+// of path, or the zero Version if no such version.
+//
+// If the newest version has been deleted after listing, try the older one.
+func (z *zkStore) getVersionAsOf(path string, at int64) (Version, error) {
+	cs, _, err := z.zk.Children(path)
+	if err == zk.ErrNoNode {
+		return Version{}, nil
+	} else if err != nil {
+		return Version{}, err
+	}
+
+	versions := make([]int64, 0, len(cs))
+	for _, c := range cs {
+		if t, err := types.ToInt64(c); err == nil && t > 0 && t <= at {
+			versions = append(versions, t)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	for _, t := range versions {
+		data, _, err := z.zk.Get(fmt.Sprintf("%s/%d", path, t))
+		if err == zk.ErrNoNode {
+			continue
+		} else if err != nil {
+			return Version{}, err
+		}
+		return Version{Time: t, Value: string(data)}, nil
+	}
+	return Version{}, nil
+}
+
+// RollbackKey re-publishes the value of the key at _time as a new version,
+// and records the rollback.
+func (z *zkStore) RollbackKey(dc, env, app, key string, _time int64) (int64,
+	error) {
+
+	path := z.path("/%s/%s/%s/%s", dc, env, app, key)
+	data, _, err := z.zk.Get(fmt.Sprintf("%s/%d", path, _time))
+	if err == zk.ErrNoNode {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	rbpath := z.getRollbackPath(dc, env, app, key)
+	if err = z.ensurePath(rbpath); err != nil {
+		return 0, err
+	}
+
+	// Create the new version and the record of the rollback together.
+	now := time.Now().Unix()
+	_, err = z.zk.Multi(
+		&zk.CreateRequest{Path: fmt.Sprintf("%s/%d", path, now), Data: data,
+			Acl: z.acl, Flags: z.flags},
+		&zk.CreateRequest{Path: fmt.Sprintf("%s/%d", rbpath, now),
+			Data: []byte(strconv.FormatInt(_time, 10)), Acl: z.acl,
+			Flags: z.flags},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return now, z.addEvent(Event{Dc: dc, Env: env, App: app, Key: key,
+		Time: now, Value: string(data)})
+}
+
+// GetRollbacks returns the records of the rollback of the key
+// in the descending order of the time.
+func (z *zkStore) GetRollbacks(dc, env, app, key string) ([]Rollback, error) {
+	path := z.getRollbackPath(dc, env, app, key)
+	cs, _, err := z.zk.Children(path)
+	if err == zk.ErrNoNode {
+		return []Rollback{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(cs)))
+
+	result := make([]Rollback, len(cs))
+	for i, c := range cs {
+		data, _, err := z.zk.Get(fmt.Sprintf("%s/%s", path, c))
+		if err != nil {
+			return nil, err
+		}
+		if result[i].Time, err = types.ToInt64(c); err != nil {
+			return nil, err
+		}
+		if result[i].Target, err = types.ToInt64(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
 func (z *zkStore) getCbPath(dc, env, app, key string) string {
 	return z.cbPath("/%s#%s#%s#%s", dc, env, app, key)
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// heartbeatInterval is the interval to send the heartbeat to the stream.
+const heartbeatInterval = 15 * time.Second
+
+var (
+	errSlowSubscriber = fmt.Errorf("the subscriber is too slow")
+
+	upgrader = websocket.Upgrader{}
+)
+
+// getLastEventID returns the id of the last event received by the client,
+// which is the header Last-Event-ID or the query argument last_event_id.
+//
+// Return -1 if neither of them is given.
+func getLastEventID(r *http.Request) (int64, error) {
+	id := r.Header.Get("Last-Event-ID")
+	if id == "" {
+		if id = r.URL.Query().Get("last_event_id"); id == "" {
+			return -1, nil
+		}
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// streamEvents sends the change events received by the subscriber in turn,
+// and sends the heartbeat periodically, until done is closed or failed.
+//
+// If last is not negative, the events after it will be replayed firstly.
+// The events received by the subscriber are sent unless replayed, even if
+// their ids are less than last, since the events may become visible out of
+// the order of the id.
+func streamEvents(sub *subscriber, last int64, done <-chan struct{},
+	send func(store.Event) error, heartbeat func() error) error {
+
+	// Replay the events missed by the client.
+	replayed := make(map[int64]struct{})
+	for last >= 0 {
+		events, err := backend.GetEvents(last, 100)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if sub.match(event) {
+				if err = send(event); err != nil {
+					return err
+				}
+			}
+			replayed[event.ID] = struct{}{}
+			last = event.ID
+		}
+
+		if len(events) < 100 {
+			break
+		}
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case event, ok := <-sub.events:
+			if !ok {
+				return errSlowSubscriber
+			} else if _, ok = replayed[event.ID]; ok {
+				continue
+			}
+
+			if err := send(event); err != nil {
+				return err
+			}
+		case <-ticker.C:
+			if err := heartbeat(); err != nil {
+				return err
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// StreamEvents pushes the change events of all the keys in dc and env,
+// or only in the app, by Server-Sent Events, or WebSocket if the request
+// is the WebSocket upgrade.
+func StreamEvents(w http.ResponseWriter, r *http.Request) error {
+	last, err := getLastEventID(r)
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	vs := mux.Vars(r)
+	sub := eventWatcher.Subscribe(vs["dc"], vs["env"], vs["app"], "")
+	defer eventWatcher.Unsubscribe(sub)
+
+	if websocket.IsWebSocketUpgrade(r) {
+		return streamWebSocket(w, r, sub, last)
+	}
+	return streamSSE(w, r, sub, last)
+}
+
+func streamSSE(w http.ResponseWriter, r *http.Request, sub *subscriber,
+	last int64) error {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return http2.String(w, http.StatusInternalServerError,
+			"not support streaming")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event store.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		_type := "set"
+		if event.Deleted {
+			_type = "delete"
+		}
+
+		_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID,
+			_type, data)
+		flusher.Flush()
+		return err
+	}
+
+	heartbeat := func() error {
+		_, err := fmt.Fprint(w, ": heartbeat\n\n")
+		flusher.Flush()
+		return err
+	}
+
+	err := streamEvents(sub, last, r.Context().Done(), send, heartbeat)
+	if err == errSlowSubscriber {
+		// The client will reconnect with the header Last-Event-ID.
+		return nil
+	}
+	return err
+}
+
+func streamWebSocket(w http.ResponseWriter, r *http.Request, sub *subscriber,
+	last int64) error {
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Read and discard the messages from the client in order to handle
+	// the control messages and detect the closing of the connection.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(event store.Event) error {
+		conn.SetWriteDeadline(time.Now().Add(heartbeatInterval))
+		return conn.WriteJSON(event)
+	}
+
+	heartbeat := func() error {
+		deadline := time.Now().Add(heartbeatInterval)
+		return conn.WriteControl(websocket.PingMessage, nil, deadline)
+	}
+
+	err = streamEvents(sub, last, done, send, heartbeat)
+	if err == errSlowSubscriber {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater,
+			err.Error())
+		deadline := time.Now().Add(heartbeatInterval)
+		conn.WriteControl(websocket.CloseMessage, msg, deadline)
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// getAppConfigByTag returns the values of the keys of the app pinned by
+// the tag, and the newest version of them, like getAppConfig.
+//
+// The pinned versions cannot be deleted, so it returns an error if one of
+// them does not exist.
+func getAppConfigByTag(dc, env, app, name string) (map[string]string, int64,
+	error) {
+	tag, err := backend.GetTag(dc, env, app, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var version int64
+	kvs := make(map[string]string, len(tag.Versions))
+	for key, t := range tag.Versions {
+		v, _, err := backend.AppGetConfig(dc, env, app, key, t)
+		if err == store.ErrNotFound {
+			return nil, 0, fmt.Errorf("the version %d of the key '%s' pinned by the tag '%s' does not exist",
+				t, key, name)
+		} else if err != nil {
+			return nil, 0, err
+		}
+
+		kvs[key] = v
+		if t > version {
+			version = t
+		}
+	}
+
+	if len(kvs) == 0 {
+		return nil, 0, store.ErrNotFound
+	}
+	return kvs, version, nil
+}
+
+// getTaggedVersion returns the version of the key pinned by the tag.
+func getTaggedVersion(dc, env, app, key, name string) (int64, error) {
+	tag, err := backend.GetTag(dc, env, app, name)
+	if err != nil {
+		return 0, err
+	}
+	if version, ok := tag.Versions[key]; ok {
+		return version, nil
+	}
+	return 0, store.ErrNotFound
+}
+
+// taggedError returns the error of deleting the versions pinned by a tag,
+// which is rendered as 409.
+func taggedError(format string, args ...interface{}) error {
+	return http2.NewHTTPError(http.StatusConflict, fmt.Errorf(format, args...))
+}
+
+// checkUntagged returns an error if the config to be deleted has a version
+// pinned by a tag, since the tags are immutable.
+//
+// The config is the version t of the key if t is greater than 0, or the whole
+// key, app, env or dc like DeleteConfig of the backend store.
+func checkUntagged(dc, env, app, key string, t int64) error {
+	if app == "" {
+		envs := []string{env}
+		if env == "" {
+			dcs, err := backend.GetAllDcAndEnvs()
+			if err != nil {
+				return err
+			}
+			envs = dcs[dc]
+		}
+
+		for _, env := range envs {
+			apps, err := getAllApps(dc, env)
+			if err == store.ErrNotFound {
+				continue
+			} else if err != nil {
+				return err
+			}
+			for _, app := range apps {
+				if err = checkUntagged(dc, env, app, "", 0); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	tags, err := backend.GetTags(dc, env, app)
+	if err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if key == "" {
+			if len(tag.Versions) > 0 {
+				return taggedError("the app '%s' is pinned by the tag '%s'",
+					app, tag.Name)
+			}
+		} else if version, ok := tag.Versions[key]; ok && (t < 1 || t == version) {
+			return taggedError("the version %d of the key '%s' is pinned by the tag '%s'",
+				version, key, tag.Name)
+		}
+	}
+	return nil
+}
+
+// CreateTag creates a tag of the app, which pins the latest version of every
+// key of the app.
+func CreateTag(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	tag, err := backend.CreateTag(vs["dc"], vs["env"], vs["app"], vs["name"])
+	printLog(err, "Create the tag: dc=%s, env=%s, app=%s, name=%s", vs["dc"],
+		vs["env"], vs["app"], vs["name"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"tag": tag})
+}
+
+// GetTags returns all the tags of the app.
+func GetTags(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	tags, err := backend.GetTags(vs["dc"], vs["env"], vs["app"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	if tags == nil {
+		tags = []store.Tag{}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"tags": tags})
+}
+
+// GetTag returns the tag of the app.
+func GetTag(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	tag, err := backend.GetTag(vs["dc"], vs["env"], vs["app"], vs["name"])
+	if err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"tag": tag})
+}
+
+// DeleteTag deletes the tag of the app.
+func DeleteTag(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	err := backend.DeleteTag(vs["dc"], vs["env"], vs["app"], vs["name"])
+	printLog(err, "Delete the tag: dc=%s, env=%s, app=%s, name=%s", vs["dc"],
+		vs["env"], vs["app"], vs["name"])
+	return renderError(w, err)
+}
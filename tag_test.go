@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestRemoveTaggedConfig(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v1")
+	tag, err := backend.CreateTag("bj", "dev", "a", "v1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.SetKeyValue("bj", "dev", "a", "k2", "v2")
+	backend.SetKeyValue("bj", "dev", "b", "k1", "v1")
+
+	cases := []struct {
+		app, key string
+		time     int64
+		purge    bool
+	}{
+		{"a", "k1", tag.Versions["k1"], false},
+		{"a", "k1", 0, false},
+		{"a", "k1", 0, true},
+		{"a", "", 0, false},
+		{"", "", 0, true},
+	}
+	for i, c := range cases {
+		env := "dev"
+		if c.app == "" {
+			env = ""
+		}
+		if _, err := removeConfig("bj", env, c.app, c.key, c.time, c.purge); err == nil {
+			t.Errorf("%d: expected the error of the tag", i)
+		}
+	}
+
+	if _, err := removeConfig("bj", "dev", "a", "k2", 0, true); err != nil {
+		t.Error(err)
+	}
+	if _, err := removeConfig("bj", "dev", "b", "", 0, true); err != nil {
+		t.Error(err)
+	}
+	if kvs, _, err := getAppConfigByTag("bj", "dev", "a", "v1.0"); err != nil ||
+		kvs["k1"] != "v1" {
+		t.Errorf("unexpected tagged config %v: %v", kvs, err)
+	}
+}
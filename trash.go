@@ -0,0 +1,184 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+// removeConfig deletes the config permanently if purge is true or deleting
+// a version of the key, or moves it into the trash.
+//
+// The config in the protected envs cannot be removed directly, so it returns
+// 403. The versions pinned by the tags are immutable, so it returns 409 if
+// the config has one of them.
+//
+// Return the trash if moved into the trash, or nil.
+func removeConfig(dc, env, app, key string, t int64, purge bool) (
+	*store.Trash, error) {
+	if err := checkUnprotected(dc, env); err != nil {
+		return nil, err
+	} else if err = checkUntagged(dc, env, app, key, t); err != nil {
+		return nil, err
+	}
+
+	if purge || t > 0 {
+		return nil, deleteConfig(dc, env, app, key, t)
+	}
+
+	trash, err := backend.TrashConfig(dc, env, app, key)
+	printLog(err, "Trash dc=%s, env=%s, app=%s, key=%s", dc, env, app, key)
+	if err != nil {
+		return nil, err
+	}
+	return &trash, nil
+}
+
+// renderRemove removes the config like removeConfig, the argument purge
+// of which is given by the query argument purge, and renders the result.
+func renderRemove(w http.ResponseWriter, r *http.Request, dc, env, app,
+	key string, t int64) error {
+	purge, err := getQueryBool(r.URL.Query(), "purge")
+	if err != nil {
+		return http2.Error(w, err, http.StatusBadRequest)
+	}
+
+	trash, err := removeConfig(dc, env, app, key, t, purge)
+	if err != nil || trash == nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"trash": trash})
+}
+
+// notifyRestored notifies the callbacks of all the keys restored
+// from the trash with their latest values.
+func notifyRestored(trash store.Trash) error {
+	if trash.Key != "" {
+		return notifyMoved(trash.Dc, trash.Env, trash.App, trash.Key)
+	} else if trash.App != "" {
+		return notifyMoved(trash.Dc, trash.Env, trash.App)
+	}
+
+	envs := []string{trash.Env}
+	if trash.Env == "" {
+		dcs, err := backend.GetAllDcAndEnvs()
+		if err != nil {
+			return err
+		}
+		envs = dcs[trash.Dc]
+	}
+
+	for _, env := range envs {
+		apps, err := getAllApps(trash.Dc, env)
+		if err == store.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for _, app := range apps {
+			if err = notifyMoved(trash.Dc, env, app); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetTrashes returns all the config in the trash.
+func GetTrashes(w http.ResponseWriter, r *http.Request) error {
+	trashes, err := backend.GetTrashes()
+	if err != nil {
+		return renderError(w, err)
+	}
+	if trashes == nil {
+		trashes = []store.Trash{}
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"trash": trashes})
+}
+
+// getTrash returns the config in the trash by the id.
+func getTrash(id string) (store.Trash, error) {
+	trashes, err := backend.GetTrashes()
+	if err != nil {
+		return store.Trash{}, err
+	}
+	for _, trash := range trashes {
+		if trash.ID == id {
+			return trash, nil
+		}
+	}
+	return store.Trash{}, store.ErrNotFound
+}
+
+// RestoreTrash restores the config in the trash, together with its history
+// and callbacks, then notifies the callbacks.
+//
+// The config of the protected envs cannot be restored directly, and the whole
+// dc cannot be restored if any env in it may be protected.
+func RestoreTrash(w http.ResponseWriter, r *http.Request) error {
+	id := mux.Vars(r)["id"]
+	trash, err := getTrash(id)
+	if err != nil {
+		return renderError(w, err)
+	} else if trash.Env == "" && isProtectedDc(trash.Dc) {
+		return http2.String(w, http.StatusForbidden,
+			"the dc '%s' may have the protected envs", trash.Dc)
+	} else if trash.Env != "" && isProtected(trash.Dc, trash.Env) {
+		return renderProtected(w, trash.Dc, trash.Env)
+	}
+
+	trash, err = backend.RestoreTrash(id)
+	printLog(err, "Restore the trash id=%s", id)
+	if err != nil {
+		return renderError(w, err)
+	}
+
+	if err = notifyRestored(trash); err != nil {
+		return renderError(w, err)
+	}
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"trash": trash})
+}
+
+// PurgeTrash deletes the config in the trash permanently.
+func PurgeTrash(w http.ResponseWriter, r *http.Request) error {
+	id := mux.Vars(r)["id"]
+	err := backend.PurgeTrash(id)
+	printLog(err, "Purge the trash id=%s", id)
+	return renderError(w, err)
+}
+
+// purgeExpiredTrashes purges the config, which has been in the trash
+// for longer than retention, every interval. It never returns.
+//
+// If retention is 0 or negative, it returns immediately and the config is
+// kept in the trash until purged explicitly.
+func purgeExpiredTrashes(retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	for {
+		trashes, err := backend.GetTrashes()
+		if err != nil {
+			logger.Errorf("cannot get the trashes: %s", err)
+		}
+
+		expired := time.Now().Add(-retention).Unix()
+		for _, trash := range trashes {
+			if trash.Time > expired {
+				break
+			}
+
+			// Another instance may have purged it.
+			err = backend.PurgeTrash(trash.ID)
+			if err != nil && err != store.ErrNotFound {
+				logger.Errorf("cannot purge the trash[%s]: %s", trash.ID, err)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
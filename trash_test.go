@@ -0,0 +1,77 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestTrashRecords(t *testing.T) {
+	backend = store.NewMemoryStore()
+	backend.CreateDcAndEnv("bj", "dev")
+	backend.SetKeyValue("bj", "dev", "a", "k1", "v1")
+	backend.SetDraft("bj", "dev", "a", "k1", "v2")
+	backend.AddCanary(store.Canary{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "v3", Instances: []string{"i1"}})
+	backend.AddSchedule(store.Schedule{Dc: "bj", Env: "dev", App: "a", Key: "k1",
+		Value: "v4", At: 4102444800})
+
+	count := func() (n int) {
+		ds, _ := backend.GetDrafts("bj", "dev", "a")
+		cs, _ := backend.GetCanaries("bj", "dev", "a")
+		ss, _ := backend.GetSchedules("bj", "dev", "a")
+		ts, _ := backend.GetTags("bj", "dev", "a")
+		return len(ds) + len(cs) + len(ss) + len(ts)
+	}
+
+	trash, err := removeConfig("bj", "dev", "a", "k1", 0, false)
+	if err != nil {
+		t.Fatal(err)
+	} else if n := count(); n != 0 {
+		t.Errorf("expected no records, but got %d", n)
+	}
+
+	q := configQuery{Client: configClient{Instance: "i1"}}
+	if _, _, _, _, err = getKeyConfig("bj", "dev", "a", "k1", q); err != store.ErrNotFound {
+		t.Errorf("expected no canary of the trashed key, but got %v", err)
+	}
+
+	if _, err = backend.RestoreTrash(trash.ID); err != nil {
+		t.Fatal(err)
+	} else if n := count(); n != 3 {
+		t.Errorf("expected 3 restored records, but got %d", n)
+	}
+	if v, _, _, _, err := getKeyConfig("bj", "dev", "a", "k1", q); err != nil || v != "v3" {
+		t.Errorf("expected the candidate value, but got '%s': %v", v, err)
+	}
+
+	// The tags are moved only with the whole app.
+	backend.CreateTag("bj", "dev", "a", "t1")
+	app, err := backend.TrashConfig("bj", "dev", "a", "")
+	if err != nil {
+		t.Fatal(err)
+	} else if n := count(); n != 0 {
+		t.Errorf("expected no records, but got %d", n)
+	}
+
+	// The draft created again conflicts with the restored one.
+	backend.SetKeyValue("bj", "dev", "a", "k2", "v1")
+	backend.SetDraft("bj", "dev", "a", "k1", "v5")
+	if _, err = backend.RestoreTrash(app.ID); err != store.ErrExist {
+		t.Errorf("expected ErrExist, but got %v", err)
+	}
+
+	if err = backend.PurgeTrash(app.ID); err != nil {
+		t.Fatal(err)
+	} else if _, err = backend.RestoreTrash(app.ID); err != store.ErrNotFound {
+		t.Errorf("expected ErrNotFound, but got %v", err)
+	}
+
+	// Deleting the app permanently deletes its records, too.
+	backend.CreateTag("bj", "dev", "a", "t2")
+	if err = backend.DeleteConfig("bj", "dev", "a", "", 0); err != nil {
+		t.Fatal(err)
+	} else if n := count(); n != 0 {
+		t.Errorf("expected no records, but got %d", n)
+	}
+}
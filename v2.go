@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/xgfone/appconfig/store"
+	"github.com/xgfone/go-tools/net2/http2"
+	"github.com/xgfone/go-tools/types"
+)
+
+// v2ErrorCodes is the error codes of the API v2 by the status code.
+var v2ErrorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_argument",
+	http.StatusUnauthorized:          "unauthenticated",
+	http.StatusForbidden:             "permission_denied",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusPreconditionFailed:    "failed_precondition",
+	http.StatusUnprocessableEntity:   "unprocessable",
+	http.StatusInternalServerError:   "internal",
+	http.StatusNotImplemented:        "unimplemented",
+	http.StatusServiceUnavailable:    "unavailable",
+	http.StatusRequestTimeout:        "timeout",
+	http.StatusRequestEntityTooLarge: "too_large",
+}
+
+// v2Error is the uniform JSON envelope of the errors of the API v2.
+type v2Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details"`
+}
+
+// renderV2Error renders the error envelope with the status code, and 406
+// returned by the API v1 when the record has existed is rendered as 409.
+//
+// If message is empty, use the text of the status code instead.
+func renderV2Error(w http.ResponseWriter, code int, message string,
+	details interface{}) error {
+	e := v2Error{Code: v2ErrorCodes[code], Message: message, Details: details}
+
+	if code == http.StatusNotAcceptable {
+		code = http.StatusConflict
+		e.Code = "already_exists"
+		if e.Message == "" {
+			e.Message = "the record has existed"
+		}
+	}
+	if e.Code == "" {
+		e.Code = strings.Replace(strings.ToLower(http.StatusText(code)), " ",
+			"_", -1)
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(code)
+	}
+
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("ETag")
+	h.Del("Last-Modified")
+	return http2.JSON(w, code, e)
+}
+
+// renderV2StoreError renders the error returned by the store like renderError.
+func renderV2StoreError(w http.ResponseWriter, err error) error {
+	switch err {
+	case nil:
+		return nil
+	case store.ErrExist:
+		return renderV2Error(w, http.StatusNotAcceptable, "", nil)
+	case store.ErrNotFound:
+		return renderV2Error(w, http.StatusNotFound, "", nil)
+	case store.ErrNoDcAndEnv:
+		return renderV2Error(w, http.StatusBadRequest, "no dc and env", nil)
+	case store.ErrConflict:
+		return renderV2Error(w, http.StatusConflict,
+			"the record has been changed by others", nil)
+	}
+
+	logger.Errorf("Get an error: %s", err)
+	if e, ok := err.(http2.HTTPError); ok {
+		return renderV2Error(w, e.Code, e.Error(), nil)
+	}
+	return renderV2Error(w, http.StatusInternalServerError, err.Error(), nil)
+}
+
+// v2Writer converts the error responses written by the handlers of the API v1
+// into the error envelope, and passes through the others.
+type v2Writer struct {
+	http.ResponseWriter
+	code int
+	buf  *bytes.Buffer // Only buffer the error response.
+}
+
+func (w *v2Writer) WriteHeader(code int) {
+	if w.code != 0 {
+		return
+	}
+
+	w.code = code
+	if code >= http.StatusBadRequest {
+		w.buf = bytes.NewBuffer(nil)
+	} else {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *v2Writer) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buf != nil {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush implements http.Flusher for the streaming.
+func (w *v2Writer) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok && w.buf == nil {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker for the WebSocket.
+func (w *v2Writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("not support hijacking")
+}
+
+// finish renders the error envelope if the handler has failed.
+func (w *v2Writer) finish(err error) {
+	switch {
+	case w.buf != nil:
+		body := bytes.TrimSpace(w.buf.Bytes())
+		if len(body) > 0 && body[0] == '{' && json.Valid(body) {
+			renderV2Error(w.ResponseWriter, w.code, "", json.RawMessage(body))
+		} else {
+			renderV2Error(w.ResponseWriter, w.code, string(body), nil)
+		}
+	case w.code == 0 && err != nil:
+		renderV2Error(w.ResponseWriter, http.StatusInternalServerError,
+			err.Error(), nil)
+	}
+}
+
+// v2Handler wraps the handler of the API v1 as that of the API v2, which
+// renders the errors as the error envelope.
+func v2Handler(f func(http.ResponseWriter, *http.Request) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_w := &v2Writer{ResponseWriter: w}
+		_w.finish(f(_w, r))
+	})
+}
+
+// registerV2Routes registers the routes of the API v2, which are the same as
+// the API v1, but the errors are rendered as the error envelope, and the lists
+// are rendered as the ordered arrays.
+func registerV2Routes(v *mux.Router) {
+	registerRoutes(v, v2Handler)
+
+	// Render the lists as the ordered arrays instead of the maps,
+	// the handlers of which render the error envelope by themselves.
+	wrap := http2.ErrorHandler
+	v.Get("GetAllDcAndEnvs").Handler(wrap(GetAllDcAndEnvsV2))
+	v.Get("GetAllValues").Handler(wrap(GetAllValuesV2))
+	v.Get("GetCallback").Handler(wrap(GetCallbackV2))
+	v.Get("GetCallbackResult").Handler(wrap(GetCallbackResultV2))
+
+	v.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renderV2Error(w, http.StatusNotFound, "no the route", nil)
+	})
+}
+
+// getV2Page returns the query arguments page and size.
+func getV2Page(w http.ResponseWriter, r *http.Request) (page, size int64,
+	ok bool) {
+	query := r.URL.Query()
+	page, err := http2.GetQueryInt64(query, "page")
+	if err != nil {
+		renderV2Error(w, http.StatusBadRequest, "invalid page", nil)
+		return
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	size, err = http2.GetQueryInt64(query, "size")
+	if err != nil {
+		renderV2Error(w, http.StatusBadRequest, "invalid size", nil)
+		return
+	}
+	if size < 1 {
+		size = 20
+	}
+	return page, size, true
+}
+
+// dcEnvs is the envs in a dc.
+type dcEnvs struct {
+	Dc   string   `json:"dc"`
+	Envs []string `json:"envs"`
+}
+
+// GetAllDcAndEnvsV2 returns all the dcs and their envs in the order of the name.
+func GetAllDcAndEnvsV2(w http.ResponseWriter, r *http.Request) error {
+	v, err := backend.GetAllDcAndEnvs()
+	if err != nil {
+		return renderV2StoreError(w, err)
+	}
+
+	dcs := make([]dcEnvs, 0, len(v))
+	for dc, envs := range v {
+		envs = append([]string{}, envs...)
+		sort.Strings(envs)
+		dcs = append(dcs, dcEnvs{Dc: dc, Envs: envs})
+	}
+	sort.Slice(dcs, func(i, j int) bool { return dcs[i].Dc < dcs[j].Dc })
+	return http2.JSON(w, http.StatusOK, map[string]interface{}{"dcs": dcs})
+}
+
+// versionValue is a value of the key with its version.
+type versionValue struct {
+	Version int64  `json:"version"`
+	Value   string `json:"value"`
+}
+
+// GetAllValuesV2 returns the values of the key in the order of the version.
+func GetAllValuesV2(w http.ResponseWriter, r *http.Request) error {
+	page, size, ok := getV2Page(w, r)
+	if !ok {
+		return nil
+	}
+
+	query := r.URL.Query()
+	from, err := http2.GetQueryInt64(query, "from")
+	if err != nil {
+		return renderV2Error(w, http.StatusBadRequest, "invalid from", nil)
+	}
+	to, err := http2.GetQueryInt64(query, "to")
+	if err != nil {
+		return renderV2Error(w, http.StatusBadRequest, "invalid to", nil)
+	}
+
+	vs := mux.Vars(r)
+	total, v, err := backend.GetAllValues(vs["dc"], vs["env"], vs["app"],
+		vs["key"], page, size, from, to)
+	if err == nil {
+		err = maskValues(vs["dc"], vs["env"], vs["app"], vs["key"], v)
+	}
+	if err != nil {
+		return renderV2StoreError(w, err)
+	}
+
+	values := make([]versionValue, 0, len(v))
+	for t, value := range v {
+		values = append(values, versionValue{Version: t, Value: value})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Version < values[j].Version
+	})
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"total": total, "values": values})
+}
+
+// callbackV2 is a callback notification of the key.
+type callbackV2 struct {
+	ID       string `json:"id"`
+	Callback string `json:"callback"`
+}
+
+// GetCallbackV2 returns the callbacks of the key in the order of the id.
+func GetCallbackV2(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	v, err := backend.GetCallback(vs["dc"], vs["env"], vs["app"], vs["key"])
+	if err != nil {
+		return renderV2StoreError(w, err)
+	}
+
+	callbacks := make([]callbackV2, 0, len(v))
+	for id, cb := range v {
+		callbacks = append(callbacks, callbackV2{ID: id, Callback: cb})
+	}
+	sort.Slice(callbacks, func(i, j int) bool {
+		return callbacks[i].ID < callbacks[j].ID
+	})
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"callbacks": callbacks})
+}
+
+// callbackResultV2 is a result of the callback notification.
+type callbackResultV2 struct {
+	Time     int64  `json:"time"`
+	Callback string `json:"callback"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error"`
+}
+
+// GetCallbackResultV2 returns the results of the callback in the order
+// of the time.
+func GetCallbackResultV2(w http.ResponseWriter, r *http.Request) error {
+	vs := mux.Vars(r)
+	v, err := backend.GetCallbackResult(vs["dc"], vs["env"], vs["app"],
+		vs["key"], vs["id"])
+	if err != nil {
+		return renderV2StoreError(w, err)
+	}
+
+	results := make([]callbackResultV2, 0, len(v))
+	for _, result := range v {
+		t, _ := types.ToInt64(result[0])
+		results = append(results, callbackResultV2{Time: t, Callback: result[1],
+			Success: result[2] == "", Error: result[2]})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Time < results[j].Time
+	})
+	return http2.JSON(w, http.StatusOK,
+		map[string]interface{}{"results": results})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xgfone/go-tools/net2/http2"
+)
+
+func TestV2Handler(t *testing.T) {
+	cases := []struct {
+		handler func(http.ResponseWriter, *http.Request) error
+		code    int
+		body    string
+	}{
+		{
+			func(w http.ResponseWriter, r *http.Request) error {
+				return http2.String(w, http.StatusBadRequest, "missing the value")
+			},
+			http.StatusBadRequest,
+			`{"code":"invalid_argument","message":"missing the value","details":null}`,
+		},
+		{
+			func(w http.ResponseWriter, r *http.Request) error {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return nil
+			},
+			http.StatusConflict,
+			`{"code":"already_exists","message":"the record has existed","details":null}`,
+		},
+		{
+			func(w http.ResponseWriter, r *http.Request) error {
+				return http2.JSON(w, http.StatusConflict, map[string]int{"version": 1})
+			},
+			http.StatusConflict,
+			`{"code":"conflict","message":"Conflict","details":{"version":1}}`,
+		},
+		{
+			func(w http.ResponseWriter, r *http.Request) error {
+				return http2.String(w, http.StatusOK, "value")
+			},
+			http.StatusOK,
+			"value",
+		},
+	}
+
+	for i, c := range cases {
+		w := httptest.NewRecorder()
+		v2Handler(c.handler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if body := w.Body.String(); w.Code != c.code || body != c.body &&
+			body != c.body+"\n" {
+			t.Errorf("%d: expected %d %s, got %d %s", i, c.code, c.body, w.Code,
+				body)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+// eventWatcher is the global watcher of the change events of the config.
+var eventWatcher = newWatcher()
+
+// eventGapTimeout is the time to wait for the missing change events before
+// skipping them. The ids of the events may be allocated by the concurrent
+// transactions of the backend store, which commit in a different order,
+// and the ids of the rolled back transactions are never filled.
+var eventGapTimeout = 30 * time.Second
+
+// subscriber is the subscriber of the change events of the config, which only
+// receives the events under dc, env, app and key. If one of them is "",
+// it receives all the events under the parent.
+//
+// If the subscriber cannot receive the events in time, the channel events
+// will be closed, and the subscriber should subscribe again.
+type subscriber struct {
+	dc, env, app, key string
+	events            chan store.Event
+}
+
+func (s *subscriber) match(e store.Event) bool {
+	if s.dc != "" && s.dc != e.Dc {
+		return false
+	}
+
+	// If the event is to delete the whole dc, env or app, the rest are "".
+	for _, v := range [][2]string{{s.env, e.Env}, {s.app, e.App}, {s.key, e.Key}} {
+		if v[0] == "" || v[1] == "" {
+			return true
+		} else if v[0] != v[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// watcher polls the change events from the backend store periodically,
+// and dispatches them to the subscribers.
+//
+// Since the change events are recorded by the backend store, the subscribers
+// can receive the changes from all the instances sharing the same store.
+//
+// The events may become visible out of the order of the id, so the watcher
+// keeps the ids of the dispatched events after last, all the events up to
+// which have been dispatched or skipped, and polls the events after last
+// again until the gap before them is filled or times out.
+type watcher struct {
+	sync.Mutex
+	last int64
+	seen map[int64]time.Time // The id to the time when dispatched.
+	subs map[*subscriber]struct{}
+}
+
+func newWatcher() *watcher {
+	return &watcher{seen: make(map[int64]time.Time),
+		subs: make(map[*subscriber]struct{})}
+}
+
+// Subscribe returns a new subscriber to receive the change events
+// under dc, env, app and key.
+func (w *watcher) Subscribe(dc, env, app, key string) *subscriber {
+	s := &subscriber{dc: dc, env: env, app: app, key: key,
+		events: make(chan store.Event, 64)}
+	w.Lock()
+	w.subs[s] = struct{}{}
+	w.Unlock()
+	return s
+}
+
+// Unsubscribe removes the subscriber.
+func (w *watcher) Unsubscribe(s *subscriber) {
+	w.Lock()
+	if _, ok := w.subs[s]; ok {
+		delete(w.subs, s)
+		close(s.events)
+	}
+	w.Unlock()
+}
+
+// Last returns the id of the change event, up to which all the events have
+// been dispatched or skipped by the watcher.
+func (w *watcher) Last() int64 {
+	w.Lock()
+	defer w.Unlock()
+	return w.last
+}
+
+// dispatch dispatches the events not dispatched yet to the subscribers,
+// then advances last.
+func (w *watcher) dispatch(events []store.Event, now time.Time) {
+	w.Lock()
+	defer w.Unlock()
+
+	for _, event := range events {
+		if _, ok := w.seen[event.ID]; ok || event.ID <= w.last {
+			continue
+		}
+		w.seen[event.ID] = now
+
+		for s := range w.subs {
+			if !s.match(event) {
+				continue
+			}
+
+			select {
+			case s.events <- event:
+			default:
+				// The subscriber is too slow, so remove it.
+				delete(w.subs, s)
+				close(s.events)
+			}
+		}
+	}
+
+	w.advance(now)
+}
+
+// advance advances last over the dispatched events, and skips the gap
+// before the first one of the rest if it has waited for eventGapTimeout.
+func (w *watcher) advance(now time.Time) {
+	for len(w.seen) > 0 {
+		if _, ok := w.seen[w.last+1]; ok {
+			w.last++
+			delete(w.seen, w.last)
+			continue
+		}
+
+		first := int64(-1)
+		for id := range w.seen {
+			if first < 0 || id < first {
+				first = id
+			}
+		}
+		if now.Sub(w.seen[first]) < eventGapTimeout {
+			return
+		}
+		w.last = first - 1
+	}
+}
+
+// Run polls the change events from the backend store every interval,
+// which never returns.
+func (w *watcher) Run(interval time.Duration) {
+	const number = 100
+
+	for {
+		last, err := backend.GetLastEventID()
+		if err == nil {
+			w.Lock()
+			w.last = last
+			w.Unlock()
+			break
+		}
+		logger.Errorf("cannot get the last change event: %s", err)
+		time.Sleep(interval)
+	}
+
+	for {
+		// Poll all the events after last, including the dispatched ones
+		// after the gap, which are skipped by dispatch.
+		for since := w.Last(); ; {
+			events, err := backend.GetEvents(since, number)
+			if err != nil {
+				logger.Errorf("cannot get the change events: %s", err)
+				break
+			}
+
+			w.dispatch(events, time.Now())
+			if len(events) < number {
+				break
+			}
+			since = events[len(events)-1].ID
+		}
+
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xgfone/appconfig/store"
+)
+
+func TestWatcherGap(t *testing.T) {
+	w := newWatcher()
+	w.last = 9
+	sub := w.Subscribe("bj", "", "", "")
+	now := time.Now()
+
+	// The event 11 is visible before the event 10.
+	w.dispatch([]store.Event{{ID: 11, Dc: "bj"}}, now)
+	if last := w.Last(); last != 9 {
+		t.Errorf("expected the last event 9, but got %d", last)
+	}
+	w.dispatch([]store.Event{{ID: 10, Dc: "bj"}, {ID: 11, Dc: "bj"}}, now)
+	if last := w.Last(); last != 11 {
+		t.Errorf("expected the last event 11, but got %d", last)
+	}
+
+	// The gap of the event 12 is skipped after the timeout.
+	w.dispatch([]store.Event{{ID: 13, Dc: "bj"}}, now)
+	w.dispatch([]store.Event{{ID: 13, Dc: "bj"}}, now.Add(eventGapTimeout/2))
+	if last := w.Last(); last != 11 {
+		t.Errorf("expected the last event 11, but got %d", last)
+	}
+	w.dispatch(nil, now.Add(eventGapTimeout))
+	if last := w.Last(); last != 13 {
+		t.Errorf("expected the last event 13, but got %d", last)
+	}
+
+	var ids []int64
+	for len(sub.events) > 0 {
+		ids = append(ids, (<-sub.events).ID)
+	}
+	if len(ids) != 3 || ids[0] != 11 || ids[1] != 10 || ids[2] != 13 {
+		t.Errorf("expected the events [11 10 13] once, but got %v", ids)
+	}
+}